@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"github.com/gin-gonic/gin"
 	"github.com/sandroJayas/user-service/config"
 	"github.com/sandroJayas/user-service/controllers"
+	"github.com/sandroJayas/user-service/domain/notification"
+	domainrepo "github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/infrastructure/captcha"
+	"github.com/sandroJayas/user-service/infrastructure/mailer"
 	"github.com/sandroJayas/user-service/infrastructure/repository"
+	"github.com/sandroJayas/user-service/infrastructure/sms"
+	"github.com/sandroJayas/user-service/models"
 	"github.com/sandroJayas/user-service/routes"
 	"github.com/sandroJayas/user-service/usecase"
 	"github.com/sandroJayas/user-service/utils"
@@ -25,17 +33,192 @@ func main() {
 	defer utils.Logger.Sync()
 
 	config.LoadEnv()
+	if err := utils.InitKeys(config.AppConfig.JWTKeysDir, config.AppConfig.JWTSigningKeyID, config.AppConfig.JWTSecret); err != nil {
+		utils.Logger.Fatal("failed to load JWT signing keys", zap.Error(err))
+	}
 	db := config.ConnectDB()
 
 	userRepo := repository.NewGormUserRepository(db)
-	userService := usecase.NewUserService(userRepo)
-	userController := controllers.NewUserController(userService)
+	refreshTokenRepo := repository.NewGormRefreshTokenRepository(db)
+	revocationRepo := repository.NewGormTokenRevocationRepository(db)
+	roleRepo := repository.NewGormRoleRepository(db)
+	oauthClientService := usecase.NewOAuthClientService(repository.NewGormOAuthClientRepository(db), usecase.OAuthClientConfig{
+		TokenTTL:       config.AppConfig.ServiceTokenTTL,
+		StatusCacheTTL: config.AppConfig.RevocationCacheTTL,
+	})
+	tokenService := usecase.NewTokenService(userRepo, refreshTokenRepo, revocationRepo, repository.NewGormSessionRepository(db), roleRepo, oauthClientService, usecase.TokenConfig{
+		AccessTTL:          config.AppConfig.AccessTokenTTL,
+		RefreshTTL:         config.AppConfig.RefreshTokenTTL,
+		RevocationCacheTTL: config.AppConfig.RevocationCacheTTL,
+	})
+	mfaKey, err := base64.StdEncoding.DecodeString(config.AppConfig.MFAEncryptionKey)
+	if err != nil {
+		utils.Logger.Fatal("invalid MFA_ENCRYPTION_KEY", zap.Error(err))
+	}
+	mfaService := usecase.NewMFAService(repository.NewGormMFARepository(db), userRepo, mfaKey, config.AppConfig.MFAIssuer)
+	webauthnService, err := usecase.NewWebAuthnService(repository.NewGormWebAuthnRepository(db), userRepo, usecase.WebAuthnConfig{
+		RPID:          config.AppConfig.WebAuthnRPID,
+		RPDisplayName: config.AppConfig.WebAuthnRPName,
+		RPOrigins:     config.AppConfig.WebAuthnRPOrigins,
+		SessionTTL:    config.AppConfig.WebAuthnSessionTTL,
+	})
+	if err != nil {
+		utils.Logger.Fatal("invalid WebAuthn configuration", zap.Error(err))
+	}
+	var breachedPasswords domainrepo.BreachedPasswordRepository
+	if path := config.AppConfig.BreachedPasswordsFile; path != "" {
+		breachedPasswords, err = repository.NewFileBreachedPasswordRepository(path)
+		if err != nil {
+			utils.Logger.Fatal("failed to load breached password list", zap.String("path", path), zap.Error(err))
+		}
+	}
+	customerPasswords := usecase.PasswordPolicy(config.AppConfig.CustomerPasswordPolicy)
+	passwordPolicies := usecase.NewPasswordPolicies(customerPasswords, map[string]usecase.PasswordPolicy{
+		models.AccountTypeCustomer: customerPasswords,
+		models.AccountTypeEmployee: usecase.PasswordPolicy(config.AppConfig.EmployeePasswordPolicy),
+	}, breachedPasswords)
+	var passwordHasher usecase.PasswordHasher
+	switch config.AppConfig.PasswordHasher {
+	case "argon2id":
+		passwordHasher = usecase.NewArgon2idHasher(usecase.Argon2Params{
+			Memory:      config.AppConfig.Argon2Memory,
+			Iterations:  config.AppConfig.Argon2Iterations,
+			Parallelism: config.AppConfig.Argon2Parallelism,
+			SaltLength:  16,
+			KeyLength:   32,
+		})
+	case "bcrypt":
+		passwordHasher = usecase.NewBcryptHasher(config.AppConfig.BcryptCost)
+	default:
+		utils.Logger.Fatal("unknown PASSWORD_HASHER", zap.String("hasher", config.AppConfig.PasswordHasher))
+	}
+	var mail notification.Mailer
+	switch config.AppConfig.Mailer {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(config.AppConfig.SMTPHost, config.AppConfig.SMTPPort, config.AppConfig.SMTPUsername, config.AppConfig.SMTPPassword, config.AppConfig.MailFrom)
+	case "log":
+		mail = mailer.NewLogMailer(config.AppConfig.MailOutboxDir)
+	default:
+		utils.Logger.Fatal("unknown MAILER", zap.String("mailer", config.AppConfig.Mailer))
+	}
+	var smsSender notification.SMSSender
+	switch config.AppConfig.SMSSender {
+	case "http":
+		smsSender = sms.NewHTTPSender(config.AppConfig.SMSHTTPURL, config.AppConfig.SMSHTTPToken, config.AppConfig.SMSFrom)
+	case "log":
+		smsSender = sms.NewLogSender(config.AppConfig.SMSOutboxDir)
+	default:
+		utils.Logger.Fatal("unknown SMS_SENDER", zap.String("sms_sender", config.AppConfig.SMSSender))
+	}
+	var loginNotifier notification.LoginNotifier
+	switch config.AppConfig.LoginNotifier {
+	case "email":
+		loginNotifier = mailer.NewLoginNotifier(mail)
+	case "none":
+	default:
+		utils.Logger.Fatal("unknown LOGIN_NOTIFIER", zap.String("login_notifier", config.AppConfig.LoginNotifier))
+	}
+	actionTokenRepo := repository.NewGormActionTokenRepository(db)
+	loginAlertService := usecase.NewLoginAlertService(repository.NewGormKnownDeviceRepository(db), actionTokenRepo, userRepo, tokenService, loginNotifier, usecase.LoginAlertConfig{
+		DisownTTL:   config.AppConfig.LoginDisownTTL,
+		FrontendURL: config.AppConfig.FrontendURL,
+	})
+	userService, err := usecase.NewUserService(userRepo, roleRepo, tokenService, repository.NewGormLoginFailureRepository(db), usecase.LockoutPolicy{
+		BackoffAfter:    config.AppConfig.LoginBackoffAfter,
+		BackoffBase:     config.AppConfig.LoginBackoffBase,
+		Threshold:       config.AppConfig.LoginLockoutThreshold,
+		LockoutDuration: config.AppConfig.LoginLockoutDuration,
+	}, passwordPolicies, passwordHasher, loginAlertService)
+	if err != nil {
+		utils.Logger.Fatal("failed to create user service", zap.Error(err))
+	}
+	recoveryService := usecase.NewRecoveryService(repository.NewGormRecoveryCodeRepository(db), userRepo, userService)
+	if email := config.AppConfig.BootstrapAdminEmail; email != "" {
+		if err := userService.EnsureAdmin(email, config.AppConfig.BootstrapAdminPassword); err != nil {
+			utils.Logger.Fatal("failed to create bootstrap admin", zap.String("email", email), zap.Error(err))
+		}
+	}
+
+	verificationService := usecase.NewEmailVerificationService(actionTokenRepo, userRepo, mail, usecase.EmailVerificationConfig{
+		TTL:            config.AppConfig.EmailVerificationTTL,
+		ResendInterval: config.AppConfig.VerificationResendInterval,
+		VerifyURL:      config.AppConfig.PublicURL + "/users/verify-email",
+		LoginURL:       config.AppConfig.FrontendURL + "/login",
+	})
+	magicLinkService := usecase.NewMagicLinkService(actionTokenRepo, userRepo, mail, config.AppConfig.MagicLinkTTL, config.AppConfig.FrontendURL)
+	passwordResetService := usecase.NewPasswordResetService(actionTokenRepo, userRepo, userService, mail, config.AppConfig.PasswordResetTTL, config.AppConfig.FrontendURL)
+	emailChangeService := usecase.NewEmailChangeService(actionTokenRepo, userRepo, tokenService, mail, usecase.EmailChangeConfig{
+		ConfirmTTL:   config.AppConfig.EmailChangeTTL,
+		RevertWindow: config.AppConfig.EmailChangeRevertWindow,
+		FrontendURL:  config.AppConfig.FrontendURL,
+	})
+
+	userController := controllers.NewUserController(userService, tokenService, mfaService, recoveryService, verificationService, magicLinkService)
+	mfaController := controllers.NewMFAController(mfaService)
+	recoveryController := controllers.NewRecoveryController(recoveryService, userService, tokenService, emailChangeService)
+	webauthnController := controllers.NewWebAuthnController(webauthnService, userService, tokenService)
+	passwordController := controllers.NewPasswordController(passwordResetService, loginAlertService)
+	verificationController := controllers.NewEmailVerificationController(verificationService)
+	emailChangeController := controllers.NewEmailChangeController(emailChangeService)
+	oauthController := controllers.NewOAuthController(tokenService, oauthClientService)
+	apiKeyService := usecase.NewAPIKeyService(repository.NewGormAPIKeyRepository(db), userRepo, roleRepo, usecase.APIKeyConfig{
+		AllowedAccountTypes: config.AppConfig.APIKeyAccountTypes,
+		MaxTTL:              config.AppConfig.APIKeyMaxTTL,
+	})
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	auditRepo := repository.NewGormAuditEventRepository(db)
+	impersonationService := usecase.NewImpersonationService(userRepo, auditRepo, tokenService, usecase.ImpersonationConfig{
+		TTL: config.AppConfig.ImpersonationTTL,
+	})
+	impersonationController := controllers.NewImpersonationController(impersonationService)
+	sessionController := controllers.NewSessionController(usecase.NewSessionService(userRepo, auditRepo, tokenService))
+	roleController := controllers.NewRoleController(usecase.NewRoleService(userRepo, roleRepo, auditRepo, tokenService))
+	phoneController := controllers.NewPhoneVerificationController(usecase.NewPhoneVerificationService(repository.NewGormPhoneVerificationRepository(db), userRepo, smsSender, usecase.PhoneVerificationConfig{
+		CodeTTL:        config.AppConfig.PhoneCodeTTL,
+		ResendInterval: config.AppConfig.PhoneCodeResendInterval,
+		MaxAttempts:    config.AppConfig.PhoneCodeMaxAttempts,
+	}))
+
+	var captchaVerifier usecase.CaptchaVerifier
+	switch config.AppConfig.CaptchaVerifier {
+	case "siteverify":
+		captchaVerifier = captcha.NewSiteVerifier(config.AppConfig.CaptchaVerifyURL, config.AppConfig.CaptchaSecret)
+	case "stub":
+		captchaVerifier = captcha.NewStubVerifier(config.AppConfig.CaptchaStubResponse)
+	case "none":
+	default:
+		utils.Logger.Fatal("unknown CAPTCHA_VERIFIER", zap.String("captcha_verifier", config.AppConfig.CaptchaVerifier))
+	}
+	botSecret, err := base64.StdEncoding.DecodeString(config.AppConfig.BotChallengeSecret)
+	if err != nil {
+		utils.Logger.Fatal("invalid BOT_CHALLENGE_SECRET", zap.Error(err))
+	}
+	if len(botSecret) == 0 {
+		utils.Logger.Warn("BOT_CHALLENGE_SECRET not set, challenges only verify on the instance that issued them")
+		botSecret = make([]byte, 32)
+		if _, err := rand.Read(botSecret); err != nil {
+			utils.Logger.Fatal("failed to generate bot challenge secret", zap.Error(err))
+		}
+	}
+	botGuard := usecase.NewBotGuard(usecase.BotGuardConfig{
+		Window:             config.AppConfig.BotChallengeWindow,
+		IPLimit:            config.AppConfig.BotChallengeIPLimit,
+		EmailDomainLimit:   config.AppConfig.BotChallengeEmailDomainLimit,
+		UserAgentLimit:     config.AppConfig.BotChallengeUserAgentLimit,
+		ExemptEmailDomains: config.AppConfig.BotChallengeExemptEmailDomains,
+		Difficulty:         config.AppConfig.BotChallengeDifficulty,
+		ChallengeTTL:       config.AppConfig.BotChallengeTTL,
+		Secret:             botSecret,
+	}, repository.NewGormBotChallengeRepository(db), captchaVerifier)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go botGuard.CleanupSpent(cleanupCtx)
 
 	shutdown := utils.InitTracer()
 	defer shutdown(context.Background())
 
 	r := gin.Default()
-	routes.RegisterUserRoutes(r, userController, db)
+	routes.RegisterUserRoutes(r, userController, mfaController, recoveryController, webauthnController, passwordController, verificationController, emailChangeController, oauthController, apiKeyController, impersonationController, phoneController, sessionController, roleController, tokenService, apiKeyService, usecase.NewServiceClients(config.AppConfig.ServiceClients), oauthClientService, impersonationService, botGuard, db)
 	r.Use(otelgin.Middleware("user-service"))
 
 	//graceful shutdown
@@ -6,15 +6,167 @@ import (
 	"github.com/sandroJayas/user-service/utils"
 	"go.uber.org/zap"
 	"log"
+	"time"
 )
 
 type EnvConfig struct {
 	DatabaseURL          string `env:"DATABASE_URL,required"`
-	JWTSecret            string `env:"JWT_SECRET,required"`
+	JWTSecret            string `env:"JWT_SECRET"`
 	AppEnv               string `env:"APP_ENV" envDefault:"testing"`
 	HoneycombServiceName string `env:"HONEYCOMB_SERVICE_NAME,required"`
 	HoneycombEndpoint    string `env:"OTEL_EXPORTER_OTLP_ENDPOINT,required"`
 	HoneycombHeaders     string `env:"OTEL_EXPORTER_OTLP_HEADERS,required"`
+
+	// JWTKeysDir holds one PEM private key per file, named <kid>.pem. When
+	// set, JWT_SECRET is ignored and tokens are signed with JWTSigningKeyID.
+	JWTKeysDir      string `env:"JWT_KEYS_DIR"`
+	JWTSigningKeyID string `env:"JWT_SIGNING_KEY_ID"`
+
+	AccessTokenTTL  time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
+	RevocationCacheTTL time.Duration `env:"REVOCATION_CACHE_TTL" envDefault:"30s"`
+
+	// ServiceClients lists static services allowed to call /oauth/introspect
+	// as client_id:secret pairs separated by commas. Clients registered
+	// through /oauth/clients are accepted as well.
+	ServiceClients  map[string]string `env:"SERVICE_CLIENTS" envSeparator:"," envKeyValSeparator:":"`
+	ServiceTokenTTL time.Duration     `env:"SERVICE_TOKEN_TTL" envDefault:"15m"`
+
+	// APIKeyAccountTypes lists the account types allowed to create personal
+	// API keys.
+	APIKeyAccountTypes []string      `env:"API_KEY_ACCOUNT_TYPES" envSeparator:"," envDefault:"employee"`
+	APIKeyMaxTTL       time.Duration `env:"API_KEY_MAX_TTL" envDefault:"2160h"`
+
+	// BootstrapAdminEmail and BootstrapAdminPassword create an employee with
+	// the admin role at startup unless an account already uses the email.
+	// Only holders of roles:assign can create employees, so a new deployment
+	// needs this once.
+	BootstrapAdminEmail    string `env:"BOOTSTRAP_ADMIN_EMAIL"`
+	BootstrapAdminPassword string `env:"BOOTSTRAP_ADMIN_PASSWORD"`
+
+	ImpersonationTTL time.Duration `env:"IMPERSONATION_TTL" envDefault:"15m"`
+
+	PhoneCodeTTL            time.Duration `env:"PHONE_CODE_TTL" envDefault:"10m"`
+	PhoneCodeResendInterval time.Duration `env:"PHONE_CODE_RESEND_INTERVAL" envDefault:"60s"`
+	PhoneCodeMaxAttempts    int           `env:"PHONE_CODE_MAX_ATTEMPTS" envDefault:"5"`
+
+	// SMSSender selects how text messages are delivered: "log" writes them
+	// to the log and, if SMS_OUTBOX_DIR is set, to files; "http" posts them
+	// to SMS_HTTP_URL.
+	SMSSender    string `env:"SMS_SENDER" envDefault:"log"`
+	SMSOutboxDir string `env:"SMS_OUTBOX_DIR"`
+	SMSHTTPURL   string `env:"SMS_HTTP_URL"`
+	SMSHTTPToken string `env:"SMS_HTTP_TOKEN"`
+	SMSFrom      string `env:"SMS_FROM" envDefault:"Sort"`
+
+	// MFAEncryptionKey is a base64 encoded 32 byte AES key for TOTP secrets.
+	MFAEncryptionKey        string        `env:"MFA_ENCRYPTION_KEY,required"`
+	MFAIssuer               string        `env:"MFA_ISSUER" envDefault:"Sort"`
+	MFAChallengeTTL         time.Duration `env:"MFA_CHALLENGE_TTL" envDefault:"5m"`
+	MFARequiredAccountTypes []string      `env:"MFA_REQUIRED_ACCOUNT_TYPES" envSeparator:","`
+
+	RecoveryTokenTTL time.Duration `env:"RECOVERY_TOKEN_TTL" envDefault:"15m"`
+
+	// RecentAuthMaxAge is how long after logging in or re-authenticating a
+	// user may do sensitive things, see middleware.RequireRecentAuth.
+	RecentAuthMaxAge time.Duration `env:"RECENT_AUTH_MAX_AGE" envDefault:"10m"`
+
+	// Per-account password guessing protection, see usecase.LockoutPolicy.
+	LoginBackoffAfter     int           `env:"LOGIN_BACKOFF_AFTER" envDefault:"3"`
+	LoginBackoffBase      time.Duration `env:"LOGIN_BACKOFF_BASE" envDefault:"1s"`
+	LoginLockoutThreshold int           `env:"LOGIN_LOCKOUT_THRESHOLD" envDefault:"10"`
+	LoginLockoutDuration  time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+
+	// WebAuthnRPID must be the registrable domain the frontend is served
+	// from; WebAuthnRPOrigins lists every origin allowed to run ceremonies.
+	WebAuthnRPID       string        `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+	WebAuthnRPName     string        `env:"WEBAUTHN_RP_NAME" envDefault:"Sort"`
+	WebAuthnRPOrigins  []string      `env:"WEBAUTHN_RP_ORIGINS" envSeparator:"," envDefault:"http://localhost:8080"`
+	WebAuthnSessionTTL time.Duration `env:"WEBAUTHN_SESSION_TTL" envDefault:"5m"`
+
+	// FrontendURL is the base of links sent to users by email.
+	FrontendURL      string        `env:"FRONTEND_URL" envDefault:"http://localhost:3000"`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"30m"`
+	MagicLinkTTL     time.Duration `env:"MAGIC_LINK_TTL" envDefault:"15m"`
+	EmailChangeTTL   time.Duration `env:"EMAIL_CHANGE_TTL" envDefault:"24h"`
+	// EmailChangeRevertWindow is how long the previous address can undo an
+	// email change.
+	EmailChangeRevertWindow time.Duration `env:"EMAIL_CHANGE_REVERT_WINDOW" envDefault:"168h"`
+
+	// PublicURL is where this service is reachable by users, for links that
+	// point straight at its endpoints.
+	PublicURL string `env:"PUBLIC_URL" envDefault:"http://localhost:8080"`
+
+	EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"48h"`
+	// VerificationResendInterval is the minimum time between two
+	// verification emails to the same account.
+	VerificationResendInterval time.Duration `env:"VERIFICATION_RESEND_INTERVAL" envDefault:"1m"`
+	LoginRequiresVerifiedEmail bool          `env:"LOGIN_REQUIRES_VERIFIED_EMAIL" envDefault:"false"`
+
+	// Bot challenges on registration and login, see usecase.BotGuard. A
+	// limit of 0 disables that signal. The email domain and user agent
+	// limits apply across all clients, so the big webmail providers are
+	// exempt by default and the user agent signal is off. BotChallengeSecret
+	// is a base64 encoded key shared by every instance; without it each
+	// instance signs challenges with a random key of its own.
+	BotChallengeWindow             time.Duration `env:"BOT_CHALLENGE_WINDOW" envDefault:"10m"`
+	BotChallengeIPLimit            int           `env:"BOT_CHALLENGE_IP_LIMIT" envDefault:"10"`
+	BotChallengeEmailDomainLimit   int           `env:"BOT_CHALLENGE_EMAIL_DOMAIN_LIMIT" envDefault:"200"`
+	BotChallengeUserAgentLimit     int           `env:"BOT_CHALLENGE_USER_AGENT_LIMIT" envDefault:"0"`
+	BotChallengeExemptEmailDomains []string      `env:"BOT_CHALLENGE_EXEMPT_EMAIL_DOMAINS" envDefault:"gmail.com,googlemail.com,outlook.com,hotmail.com,live.com,yahoo.com,icloud.com,proton.me" envSeparator:","`
+	BotChallengeDifficulty         int           `env:"BOT_CHALLENGE_DIFFICULTY" envDefault:"18"`
+	BotChallengeTTL                time.Duration `env:"BOT_CHALLENGE_TTL" envDefault:"5m"`
+	BotChallengeSecret             string        `env:"BOT_CHALLENGE_SECRET"`
+	// CaptchaVerifier lets clients answer a bot challenge with a CAPTCHA:
+	// "none", "siteverify" for reCAPTCHA, hCaptcha or Turnstile, or "stub",
+	// which accepts CAPTCHA_STUB_RESPONSE, for tests.
+	CaptchaVerifier     string `env:"CAPTCHA_VERIFIER" envDefault:"none"`
+	CaptchaVerifyURL    string `env:"CAPTCHA_VERIFY_URL"`
+	CaptchaSecret       string `env:"CAPTCHA_SECRET"`
+	CaptchaStubResponse string `env:"CAPTCHA_STUB_RESPONSE"`
+
+	// LoginNotifier selects how users hear about logins from new devices:
+	// "email" or "none". Devices are recorded either way.
+	LoginNotifier string `env:"LOGIN_NOTIFIER" envDefault:"email"`
+	// LoginDisownTTL is how long the "this wasn't me" link in an alert works.
+	LoginDisownTTL time.Duration `env:"LOGIN_DISOWN_TTL" envDefault:"168h"`
+
+	// Mailer selects how email is delivered: "log" writes messages to the
+	// log and, if MAIL_OUTBOX_DIR is set, to files; "smtp" sends them.
+	Mailer        string `env:"MAILER" envDefault:"log"`
+	MailFrom      string `env:"MAIL_FROM" envDefault:"no-reply@localhost"`
+	MailOutboxDir string `env:"MAIL_OUTBOX_DIR"`
+	SMTPHost      string `env:"SMTP_HOST"`
+	SMTPPort      int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername  string `env:"SMTP_USERNAME"`
+	SMTPPassword  string `env:"SMTP_PASSWORD"`
+
+	// PasswordHasher selects the algorithm for new password hashes,
+	// "argon2id" or "bcrypt". Existing hashes of either kind keep verifying
+	// and are rehashed on the next login when the settings change.
+	PasswordHasher    string `env:"PASSWORD_HASHER" envDefault:"argon2id"`
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY_KIB" envDefault:"19456"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"2"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"1"`
+	BcryptCost        int    `env:"BCRYPT_COST" envDefault:"12"`
+
+	// Password rules per account type, read from CUSTOMER_PASSWORD_* and
+	// EMPLOYEE_PASSWORD_*. Defaults are filled in by LoadEnv.
+	CustomerPasswordPolicy PasswordPolicyConfig `envPrefix:"CUSTOMER_PASSWORD_"`
+	EmployeePasswordPolicy PasswordPolicyConfig `envPrefix:"EMPLOYEE_PASSWORD_"`
+	// BreachedPasswordsFile is a list of breached password SHA-1 hashes in
+	// the Pwned Passwords download format. Unset disables the check.
+	BreachedPasswordsFile string `env:"BREACHED_PASSWORDS_FILE"`
+}
+
+type PasswordPolicyConfig struct {
+	MinLength      int  `env:"MIN_LENGTH"`
+	MaxLength      int  `env:"MAX_LENGTH"`
+	MinCharClasses int  `env:"MIN_CHAR_CLASSES"`
+	MinStrength    int  `env:"MIN_STRENGTH"`
+	RejectEmail    bool `env:"REJECT_EMAIL"`
+	RejectBreached bool `env:"REJECT_BREACHED"`
 }
 
 var AppConfig *EnvConfig
@@ -24,7 +176,11 @@ func LoadEnv() {
 		utils.Logger.Warn("No .env file loaded", zap.Error(err))
 	}
 
-	var cfg EnvConfig
+	cfg := EnvConfig{
+		// bcrypt ignores everything past 72 bytes.
+		CustomerPasswordPolicy: PasswordPolicyConfig{MinLength: 8, MaxLength: 72, MinCharClasses: 1, MinStrength: 1, RejectEmail: true, RejectBreached: true},
+		EmployeePasswordPolicy: PasswordPolicyConfig{MinLength: 12, MaxLength: 72, MinCharClasses: 3, MinStrength: 3, RejectEmail: true, RejectBreached: true},
+	}
 	err := env.Parse(&cfg)
 	if err != nil {
 		log.Fatalf("❌ Failed to parse environment: %v", err)
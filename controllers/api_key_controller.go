@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type APIKeyController struct {
+	keys *usecase.APIKeyService
+}
+
+func NewAPIKeyController(keys *usecase.APIKeyService) *APIKeyController {
+	return &APIKeyController{keys: keys}
+}
+
+// CreateAPIKey godoc
+// @Summary Create a personal API key
+// @Description Creates a key for scripts and integrations, sent as 'Authorization: ApiKey <key>'. The key is only shown in this response. Only account types in API_KEY_ACCOUNT_TYPES may create keys.
+// @Tags api-keys
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CreateAPIKeyRequest true "Key name, scopes and lifetime"
+// @Success 201 {object} map[string]any "Key details in 'api_key' and the key itself in 'key'"
+// @Failure 400 {object} map[string]string "Invalid input, unknown scope or lifetime too long"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "API keys not available for this account type"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/api-keys [post]
+func (ctrl *APIKeyController) CreateAPIKey(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, raw, err := ctrl.keys.CreateKey(userID, req.Name, req.Scopes, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrAPIKeysNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrInvalidAPIKeyScope):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed_scopes": usecase.APIKeyScopes})
+		case errors.Is(err, usecase.ErrInvalidAPIKeyTTL):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			utils.Logger.Error("API key creation failed", zap.String("user_id", userID.String()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create API key"})
+		}
+		return
+	}
+
+	utils.Logger.Info("API key created",
+		zap.String("event", "api_key_created"),
+		zap.String("user_id", userID.String()),
+		zap.String("api_key_id", key.ID.String()),
+		zap.Strings("scopes", key.ScopeList()),
+	)
+	c.JSON(http.StatusCreated, gin.H{"api_key": apiKeyResponse(key), "key": raw})
+}
+
+// ListAPIKeys godoc
+// @Summary List personal API keys
+// @Description Lists the caller's keys, including revoked and expired ones, with when and from where each was last used
+// @Tags api-keys
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Keys in 'api_keys'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/api-keys [get]
+func (ctrl *APIKeyController) ListAPIKeys(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	keys, err := ctrl.keys.ListKeys(userID)
+	if err != nil {
+		utils.Logger.Error("listing API keys failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list API keys"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(keys))
+	for i := range keys {
+		response = append(response, apiKeyResponse(&keys[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": response})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke a personal API key
+// @Tags api-keys
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid key ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "No active key with this ID"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/api-keys/{id} [delete]
+func (ctrl *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key ID"})
+		return
+	}
+
+	if err := ctrl.keys.RevokeKey(userID, keyID); err != nil {
+		if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("API key revocation failed", zap.String("user_id", userID.String()), zap.String("api_key_id", keyID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke API key"})
+		return
+	}
+
+	utils.Logger.Info("API key revoked",
+		zap.String("event", "api_key_revoked"),
+		zap.String("user_id", userID.String()),
+		zap.String("api_key_id", keyID.String()),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func apiKeyResponse(key *models.APIKey) gin.H {
+	return gin.H{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.ScopeList(),
+		"expires_at":   key.ExpiresAt,
+		"created_at":   key.CreatedAt,
+		"last_used_at": key.LastUsedAt,
+		"last_used_ip": key.LastUsedIP,
+		"revoked_at":   key.RevokedAt,
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type EmailChangeController struct {
+	changes *usecase.EmailChangeService
+}
+
+func NewEmailChangeController(changes *usecase.EmailChangeService) *EmailChangeController {
+	return &EmailChangeController{changes: changes}
+}
+
+// RequestEmailChange godoc
+// @Summary Change the current user's email address
+// @Description Emails a confirmation link to the new address and a revert link to the current one. The address only changes once the new one is confirmed. The response is the same whether or not the new address is already registered.
+// @Tags users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ChangeEmailRequest true "New email address"
+// @Success 202 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input or unchanged email"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/email [post]
+func (ctrl *EmailChangeController) RequestEmailChange(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.changes.RequestChange(userID, req.Email); err != nil {
+		if errors.Is(err, usecase.ErrEmailUnchanged) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("email change request failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not request email change"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Check the new address for a confirmation link"})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email address change
+// @Description Moves the account to the new address using the token from the confirmation link. The new address counts as verified.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param request body dto.EmailChangeTokenRequest true "Token from the confirmation link"
+// @Success 200 {object} map[string]any "Updated user in 'user' field"
+// @Failure 400 {object} map[string]string "Invalid or expired token"
+// @Failure 409 {object} map[string]string "Address taken by another account"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/email/confirm [post]
+func (ctrl *EmailChangeController) ConfirmEmailChange(c *gin.Context) {
+	var req dto.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.changes.Confirm(req.Token)
+	if err != nil {
+		ctrl.respondError(c, "email change confirmation failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// RevertEmailChange godoc
+// @Summary Undo an email address change
+// @Description Puts the account back on the previous address using the token sent there, cancels pending changes and revokes every session.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param request body dto.EmailChangeTokenRequest true "Token from the revert link"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid or expired token"
+// @Failure 409 {object} map[string]string "Address taken by another account"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/email/revert [post]
+func (ctrl *EmailChangeController) RevertEmailChange(c *gin.Context) {
+	var req dto.EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := ctrl.changes.Revert(req.Token); err != nil {
+		ctrl.respondError(c, "email change revert failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email address restored and every session signed out. Consider resetting your password."})
+}
+
+func (ctrl *EmailChangeController) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidEmailChangeLink):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		utils.Logger.Error(msg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update email"})
+	}
+}
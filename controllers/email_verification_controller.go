@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type EmailVerificationController struct {
+	verification *usecase.EmailVerificationService
+}
+
+func NewEmailVerificationController(verification *usecase.EmailVerificationService) *EmailVerificationController {
+	return &EmailVerificationController{verification: verification}
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirms the user's email address using the token from the verification link. Tokens issued afterwards carry email_verified=true.
+// @Tags auth
+// @Produce  json
+// @Param token query string true "Token from the verification link"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid or expired token"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/verify-email [get]
+func (ctrl *EmailVerificationController) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	user, err := ctrl.verification.Verify(token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("email verification failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify email"})
+		return
+	}
+
+	utils.Logger.Info("email verified", zap.String("user_id", user.ID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification link if the account exists, is unverified and no link was sent in the last minute. The response is the same in every case.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ResendVerificationRequest true "Account email"
+// @Success 202 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/verify-email/resend [post]
+func (ctrl *EmailVerificationController) ResendVerification(c *gin.Context) {
+	var req dto.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.verification.Resend(req.Email); err != nil {
+		utils.Logger.Error("resending verification failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not resend verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account needs verification, a new link has been sent"})
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ImpersonationController struct {
+	impersonation *usecase.ImpersonationService
+}
+
+func NewImpersonationController(impersonation *usecase.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{impersonation: impersonation}
+}
+
+// Impersonate godoc
+// @Summary Impersonate a customer
+// @Description Issues a short-lived token for a customer account, carrying the employee in an RFC 8693 'act' claim, so support staff see what the customer sees. The token only works on read-only endpoints and every request made with it is audited. Requires the users:impersonate permission with MFA.
+// @Tags users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ImpersonateRequest true "Customer to impersonate and the reason"
+// @Success 200 {object} map[string]any "Impersonation token in 'token' and its expiry in 'token_expires_at'"
+// @Failure 400 {object} map[string]string "Invalid input or not a customer account"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/impersonate [post]
+func (ctrl *ImpersonationController) Impersonate(c *gin.Context) {
+	employeeIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	employeeID := employeeIDRaw.(uuid.UUID)
+
+	var req dto.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	token, expiresAt, err := ctrl.impersonation.Start(employeeID, c.GetStringSlice("amr"), userID, req.Reason, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case errors.Is(err, usecase.ErrCannotImpersonate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			utils.Logger.Error("impersonation failed", zap.String("employee_id", employeeID.String()), zap.String("user_id", userID.String()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start impersonation"})
+		}
+		return
+	}
+
+	utils.Logger.Info("impersonation started",
+		zap.String("event", "impersonation_started"),
+		zap.String("employee_id", employeeID.String()),
+		zap.String("user_id", userID.String()),
+		zap.String("reason", req.Reason),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"token": token, "token_expires_at": expiresAt})
+}
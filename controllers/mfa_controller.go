@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type MFAController struct {
+	service *usecase.MFAService
+}
+
+func NewMFAController(service *usecase.MFAService) *MFAController {
+	return &MFAController{service: service}
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrolment
+// @Description Generates a new authenticator secret for the current user. The secret only becomes active once confirmed with a first code.
+// @Tags mfa
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]string "Secret in 'secret' and otpauth URI in 'otpauth_uri'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "MFA already enabled"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/mfa/totp [post]
+func (ctrl *MFAController) EnrollTOTP(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	enrolment, err := ctrl.service.EnrollTOTP(userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrMFAAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("totp enrolment failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start enrolment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      enrolment.Secret,
+		"otpauth_uri": enrolment.URI,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrolment
+// @Description Activates the pending authenticator secret using a first code from the app
+// @Tags mfa
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ConfirmTOTPRequest true "Code from the authenticator app"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input or code"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]string "MFA already enabled"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/mfa/totp/confirm [post]
+func (ctrl *MFAController) ConfirmTOTP(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.ConfirmTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.service.ConfirmTOTP(userID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidMFACode), errors.Is(err, usecase.ErrMFANotEnrolled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrMFAAlreadyEnabled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			utils.Logger.Error("totp confirmation failed", zap.String("user_id", userID.String()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not confirm enrolment"})
+		}
+		return
+	}
+
+	utils.Logger.Info("totp enabled", zap.String("user_id", userID.String()))
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type OAuthController struct {
+	tokens  *usecase.TokenService
+	clients *usecase.OAuthClientService
+}
+
+func NewOAuthController(tokens *usecase.TokenService, clients *usecase.OAuthClientService) *OAuthController {
+	return &OAuthController{tokens: tokens, clients: clients}
+}
+
+// Token godoc
+// @Summary Issue a service token
+// @Description OAuth 2.0 client-credentials grant for registered machine clients. Credentials go in HTTP Basic auth or the client_id and client_secret form fields. Service tokens are rejected by user endpoints.
+// @Tags oauth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param grant_type formData string true "Must be client_credentials"
+// @Param scope formData string false "Space separated subset of the client's scopes, all of them by default"
+// @Param client_id formData string false "Client ID when not using HTTP Basic auth"
+// @Param client_secret formData string false "Client secret when not using HTTP Basic auth"
+// @Success 200 {object} map[string]any "access_token, token_type, expires_in and scope"
+// @Failure 400 {object} map[string]string "unsupported_grant_type or invalid_scope"
+// @Failure 401 {object} map[string]string "invalid_client"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /oauth/token [post]
+func (ctrl *OAuthController) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if grantType := c.PostForm("grant_type"); grantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+
+	id, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		id, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	token, expiresAt, scopes, err := ctrl.clients.IssueToken(id, secret, strings.Fields(c.PostForm("scope")))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidClient):
+			utils.Logger.Warn("client authentication failed", zap.String("client_id", id))
+			c.Header("WWW-Authenticate", `Basic realm="user-service"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		case errors.Is(err, usecase.ErrInvalidScope):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope"})
+		default:
+			utils.Logger.Error("service token issuance failed", zap.String("client_id", id), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	utils.Logger.Info("service token issued", zap.String("client_id", id), zap.Strings("scopes", scopes))
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(time.Until(expiresAt).Seconds()),
+		"scope":        strings.Join(scopes, " "),
+	})
+}
+
+// Introspect godoc
+// @Summary Introspect an access token
+// @Description RFC 7662 token introspection for other services, authenticated with HTTP Basic client credentials. Inactive tokens only report 'active': false, plus 'revoked': true when they were revoked. User tokens list the 'permissions' granted by the user's roles. Impersonation tokens carry the employee in 'act'.
+// @Tags oauth
+// @Security BasicAuth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param token formData string true "Access token"
+// @Param token_type_hint formData string false "Ignored, only access tokens are supported"
+// @Success 200 {object} map[string]any "Introspection response"
+// @Failure 400 {object} map[string]string "Missing token"
+// @Failure 401 {object} map[string]string "Invalid client credentials"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /oauth/introspect [post]
+func (ctrl *OAuthController) Introspect(c *gin.Context) {
+	raw := c.PostForm("token")
+	if raw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required"})
+		return
+	}
+
+	result, err := ctrl.tokens.Introspect(raw)
+	if err != nil {
+		utils.Logger.Error("token introspection failed", zap.String("client_id", c.GetString("client_id")), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	// Introspection answers must never be reused by intermediaries.
+	c.Header("Cache-Control", "no-store")
+	if !result.Active {
+		response := gin.H{"active": false}
+		if result.Revoked {
+			response["revoked"] = true
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token := result.Token
+	if token.IsService() {
+		c.JSON(http.StatusOK, gin.H{
+			"active":     true,
+			"revoked":    false,
+			"token_type": "Bearer",
+			"sub":        token.ClientID,
+			"client_id":  token.ClientID,
+			"scope":      strings.Join(token.Scopes, " "),
+			"jti":        token.JTI,
+			"iat":        token.IssuedAt.Unix(),
+			"exp":        token.ExpiresAt.Unix(),
+		})
+		return
+	}
+	response := gin.H{
+		"active":         true,
+		"revoked":        false,
+		"token_type":     "Bearer",
+		"sub":            token.UserID.String(),
+		"account_type":   token.AccountType,
+		"amr":            token.AMR,
+		"permissions":    token.Permissions,
+		"email_verified": token.EmailVerified,
+		"jti":            token.JTI,
+		"iat":            token.IssuedAt.Unix(),
+		"exp":            token.ExpiresAt.Unix(),
+	}
+	if token.IsImpersonation() {
+		response["act"] = gin.H{"sub": token.ActorID.String()}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateClient godoc
+// @Summary Register a machine client
+// @Description Creates a client for the client-credentials grant. The secret is only shown in this response.
+// @Tags oauth
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.CreateOAuthClientRequest true "Client name and allowed scopes"
+// @Success 201 {object} map[string]any "Client in 'client' and its secret in 'client_secret'"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /oauth/clients [post]
+func (ctrl *OAuthController) CreateClient(c *gin.Context) {
+	employeeIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	employeeID := employeeIDRaw.(uuid.UUID)
+
+	var req dto.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, secret, err := ctrl.clients.CreateClient(req.Name, req.Scopes, employeeID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidScopeFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("client creation failed", zap.String("employee_id", employeeID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create client"})
+		return
+	}
+
+	utils.Logger.Info("oauth client created",
+		zap.String("event", "oauth_client_created"),
+		zap.String("client_id", client.ID.String()),
+		zap.String("employee_id", employeeID.String()),
+	)
+	c.JSON(http.StatusCreated, gin.H{"client": clientResponse(client), "client_secret": secret})
+}
+
+// ListClients godoc
+// @Summary List machine clients
+// @Description Lists every registered client, including revoked ones. Secrets are never returned.
+// @Tags oauth
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Clients in 'clients'"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /oauth/clients [get]
+func (ctrl *OAuthController) ListClients(c *gin.Context) {
+	clients, err := ctrl.clients.ListClients()
+	if err != nil {
+		utils.Logger.Error("listing clients failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list clients"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(clients))
+	for i := range clients {
+		response = append(response, clientResponse(&clients[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": response})
+}
+
+// RevokeClient godoc
+// @Summary Revoke a machine client
+// @Description Revokes the client. Its tokens stop working within the revocation cache TTL.
+// @Tags oauth
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Client ID"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid client ID"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "No active client with this ID"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /oauth/clients/{id} [delete]
+func (ctrl *OAuthController) RevokeClient(c *gin.Context) {
+	employeeIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	employeeID := employeeIDRaw.(uuid.UUID)
+
+	clientID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client ID"})
+		return
+	}
+
+	if err := ctrl.clients.RevokeClient(clientID); err != nil {
+		if errors.Is(err, usecase.ErrClientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("client revocation failed", zap.String("client_id", clientID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke client"})
+		return
+	}
+
+	utils.Logger.Info("oauth client revoked",
+		zap.String("event", "oauth_client_revoked"),
+		zap.String("client_id", clientID.String()),
+		zap.String("employee_id", employeeID.String()),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "Client revoked"})
+}
+
+func clientResponse(client *models.OAuthClient) gin.H {
+	return gin.H{
+		"client_id":    client.ID,
+		"name":         client.Name,
+		"scopes":       client.ScopeList(),
+		"created_by":   client.CreatedBy,
+		"created_at":   client.CreatedAt,
+		"last_used_at": client.LastUsedAt,
+		"revoked_at":   client.RevokedAt,
+	}
+}
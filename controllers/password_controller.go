@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type PasswordController struct {
+	reset  *usecase.PasswordResetService
+	alerts *usecase.LoginAlertService
+}
+
+func NewPasswordController(reset *usecase.PasswordResetService, alerts *usecase.LoginAlertService) *PasswordController {
+	return &PasswordController{reset: reset, alerts: alerts}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset link
+// @Description Emails a single-use reset link if an account exists for the address. The response is the same whether or not it does.
+// @Tags password
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/password/forgot [post]
+func (ctrl *PasswordController) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.reset.RequestReset(req.Email); err != nil {
+		utils.Logger.Error("password reset request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not request password reset"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for this email, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Sets a new password using the token from a reset link. The token works once and every existing session is signed out.
+// @Tags password
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]any "Invalid input or token, or a rejected password with violations in 'fields'"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/password/reset [post]
+func (ctrl *PasswordController) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.reset.Reset(req.Token, req.Password); err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		if errors.Is(err, usecase.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("password reset failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// DisownLogin godoc
+// @Summary Report a login that wasn't you
+// @Description Takes the token from the link in a new device alert. Every session is signed out, password logins are refused until the password is reset, and a reset link is emailed. The link works once.
+// @Tags password
+// @Accept  json
+// @Produce  json
+// @Param request body dto.DisownLoginRequest true "Token from the alert link"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input or link"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/login/disown [post]
+func (ctrl *PasswordController) DisownLogin(c *gin.Context) {
+	var req dto.DisownLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.alerts.Disown(req.Token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidDisownLink) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("login disown failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not secure account"})
+		return
+	}
+	// The account is already locked down; if this fails the user can still
+	// ask for a link at /users/password/forgot.
+	if err := ctrl.reset.RequestReset(user.Email); err != nil {
+		utils.Logger.Error("password reset after disown failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Every session has been signed out. Check your email to choose a new password"})
+}
+
+// respondPasswordRejected answers 400 with one entry per broken rule in
+// 'fields' if err is a *usecase.PasswordPolicyError, and reports whether it
+// did.
+func respondPasswordRejected(c *gin.Context, err error) bool {
+	var policyErr *usecase.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not meet the requirements", "fields": policyErr.Violations})
+	return true
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type PhoneVerificationController struct {
+	phones *usecase.PhoneVerificationService
+}
+
+func NewPhoneVerificationController(phones *usecase.PhoneVerificationService) *PhoneVerificationController {
+	return &PhoneVerificationController{phones: phones}
+}
+
+// SendPhoneCode godoc
+// @Summary Text a phone verification code
+// @Description Sends a six digit code to the phone number on the profile. Earlier codes stop working.
+// @Tags users
+// @Security BearerAuth
+// @Produce  json
+// @Success 202 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "No phone number, or already verified"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 429 {object} map[string]string "A code was sent recently"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/phone/code [post]
+func (ctrl *PhoneVerificationController) SendPhoneCode(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	err := ctrl.phones.SendCode(userID)
+	var throttled *usecase.PhoneCodeThrottledError
+	switch {
+	case err == nil:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Verification code sent"})
+	case errors.As(err, &throttled):
+		retryAfter := int(math.Ceil(time.Until(throttled.Until).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "A code was sent recently, try again later"})
+	case errors.Is(err, usecase.ErrNoPhoneNumber), errors.Is(err, usecase.ErrPhoneAlreadyVerified):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		utils.Logger.Error("sending phone code failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send verification code"})
+	}
+}
+
+// VerifyPhone godoc
+// @Summary Verify the phone number
+// @Description Confirms the phone number with the code texted to it. Each code allows a few attempts before a new one has to be requested.
+// @Tags users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.VerifyPhoneRequest true "Code from the text message"
+// @Success 200 {object} map[string]any "Updated user in 'user' field"
+// @Failure 400 {object} map[string]string "Invalid or expired code"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 429 {object} map[string]string "Too many wrong codes"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/phone/verify [post]
+func (ctrl *PhoneVerificationController) VerifyPhone(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.phones.Confirm(userID, req.Code)
+	switch {
+	case err == nil:
+		utils.Logger.Info("phone number verified", zap.String("event", "phone_verified"), zap.String("user_id", userID.String()))
+		c.JSON(http.StatusOK, gin.H{"user": user})
+	case errors.Is(err, usecase.ErrInvalidPhoneCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrPhoneCodeAttemptsExceeded):
+		utils.Logger.Warn("phone code attempts exceeded",
+			zap.String("event", "phone_code_attempts_exceeded"),
+			zap.String("user_id", userID.String()),
+			zap.String("ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		utils.Logger.Error("phone verification failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify phone number"})
+	}
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/config"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type RecoveryController struct {
+	recovery     *usecase.RecoveryService
+	users        *usecase.UserService
+	tokens       *usecase.TokenService
+	emailChanges *usecase.EmailChangeService
+}
+
+func NewRecoveryController(recovery *usecase.RecoveryService, users *usecase.UserService, tokens *usecase.TokenService, emailChanges *usecase.EmailChangeService) *RecoveryController {
+	return &RecoveryController{recovery: recovery, users: users, tokens: tokens, emailChanges: emailChanges}
+}
+
+// GenerateRecoveryCodes godoc
+// @Summary Generate account recovery codes
+// @Description Replaces the current user's recovery codes with a new set of single-use codes. The codes are only shown in this response.
+// @Tags recovery
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Codes in 'recovery_codes'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/recovery-codes [post]
+func (ctrl *RecoveryController) GenerateRecoveryCodes(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	codes, err := ctrl.recovery.GenerateCodes(userID)
+	if err != nil {
+		utils.Logger.Error("recovery code generation failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate recovery codes"})
+		return
+	}
+
+	utils.Logger.Info("recovery codes generated", zap.String("user_id", userID.String()))
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Recover godoc
+// @Summary Redeem a recovery code
+// @Description Exchanges an email and an unused recovery code for a short-lived recovery token. The token can only be used at /users/recover/reset. Wrong codes count towards the account lockout.
+// @Tags recovery
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RecoverAccountRequest true "Email and recovery code"
+// @Success 200 {object} map[string]any "Token in 'recovery_token' with 'expires_at'"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid email or code"
+// @Failure 429 {object} map[string]string "Account temporarily locked after failed attempts; see Retry-After"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/recover [post]
+func (ctrl *RecoveryController) Recover(c *gin.Context) {
+	var req dto.RecoverAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.recovery.Redeem(req.Email, req.Code)
+	if err != nil {
+		if respondLoginRefused(c, err) {
+			return
+		}
+		if errors.Is(err, usecase.ErrInvalidRecoveryCode) {
+			utils.Logger.Warn("recovery code rejected", zap.String("email", req.Email))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("recovery failed", zap.String("email", req.Email), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not recover account"})
+		return
+	}
+
+	token, expiresAt, err := utils.GeneratePurposeToken(user.ID, utils.TokenUseRecovery, config.AppConfig.RecoveryTokenTTL)
+	if err != nil {
+		utils.Logger.Error("recovery token generation failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not recover account"})
+		return
+	}
+
+	utils.Logger.Info("recovery code redeemed", zap.String("user_id", user.ID.String()))
+	c.JSON(http.StatusOK, gin.H{"recovery_token": token, "expires_at": expiresAt})
+}
+
+// ResetAfterRecovery godoc
+// @Summary Reset credentials with a recovery token
+// @Description Sets a new password using the token from /users/recover. The token is single-use and every existing session is revoked. A new 'email' is not applied right away: it gets a confirmation link, and the current address a revert link, as with /users/me/email.
+// @Tags recovery
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.RecoveryResetRequest true "New credentials"
+// @Success 200 {object} map[string]any "Updated user in 'user' field, and whether a confirmation link was sent to the new email in 'email_change_pending'"
+// @Failure 400 {object} map[string]any "Invalid input, or a rejected password with violations in 'fields'"
+// @Failure 401 {object} map[string]string "Invalid or used recovery token"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/recover/reset [post]
+func (ctrl *RecoveryController) ResetAfterRecovery(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.RecoveryResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check the password before burning the token so a rejected one can be
+	// retried.
+	if err := ctrl.users.ValidatePassword(userID, req.Password, req.Email); err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		utils.Logger.Error("recovery reset failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset credentials"})
+		return
+	}
+
+	if err := ctrl.tokens.ConsumeOnce(userID, c.GetString("jti"), c.GetTime("token_expires_at")); err != nil {
+		if errors.Is(err, usecase.ErrTokenAlreadyUsed) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Recovery token has already been used"})
+			return
+		}
+		utils.Logger.Error("recovery token consumption failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset credentials"})
+		return
+	}
+
+	user, err := ctrl.users.RecoverAccount(userID, req.Password)
+	if err != nil {
+		utils.Logger.Error("recovery reset failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not reset credentials"})
+		return
+	}
+
+	// The password is reset at this point, so a failed email change is only
+	// reported through email_change_pending.
+	emailChangePending := false
+	if req.Email != "" {
+		err := ctrl.emailChanges.RequestChange(userID, req.Email)
+		switch {
+		case err == nil:
+			emailChangePending = true
+		case !errors.Is(err, usecase.ErrEmailUnchanged):
+			utils.Logger.Error("recovery email change failed", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+
+	utils.Logger.Info("credentials reset via recovery code",
+		zap.String("user_id", userID.String()),
+		zap.Bool("email_change_requested", emailChangePending),
+	)
+	c.JSON(http.StatusOK, gin.H{"user": user, "email_change_pending": emailChangePending})
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type RoleController struct {
+	roles *usecase.RoleService
+}
+
+func NewRoleController(roles *usecase.RoleService) *RoleController {
+	return &RoleController{roles: roles}
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description Lists every role with the permissions it grants and the account type it can be assigned to. Requires the roles:read permission.
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Roles in 'roles'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /roles [get]
+func (ctrl *RoleController) ListRoles(c *gin.Context) {
+	roles, err := ctrl.roles.ListRoles()
+	if err != nil {
+		utils.Logger.Error("listing roles failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": rolesResponse(roles)})
+}
+
+// GetUserRoles godoc
+// @Summary List a user's roles
+// @Description Lists the roles a user holds. Requires the users:read permission.
+// @Tags roles
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]any "Roles in 'roles'"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/roles [get]
+func (ctrl *RoleController) GetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	roles, err := ctrl.roles.UserRoles(userID)
+	if err != nil {
+		ctrl.respondError(c, err, "listing user roles failed", userID)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": rolesResponse(roles)})
+}
+
+// SetUserRoles godoc
+// @Summary Change a user's roles
+// @Description Replaces the roles a user holds. Roles must match the user's account type, and callers can only grant permissions they hold themselves. The user is signed out everywhere so their next login carries the new permissions. The change is audited. Requires the roles:assign permission with MFA.
+// @Tags roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param request body dto.SetUserRolesRequest true "Role names"
+// @Success 200 {object} map[string]any "The user's roles in 'roles'"
+// @Failure 400 {object} map[string]string "Invalid input, unknown role, or a role for another account type"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden, changing your own roles, or granting a permission you lack"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/roles [put]
+func (ctrl *RoleController) SetUserRoles(c *gin.Context) {
+	actorIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	actorID := actorIDRaw.(uuid.UUID)
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var req dto.SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := ctrl.roles.SetUserRoles(actorID, c.GetStringSlice("permissions"), userID, req.Roles, c.ClientIP())
+	if err != nil {
+		ctrl.respondError(c, err, "changing user roles failed", userID)
+		return
+	}
+
+	utils.Logger.Info("user roles changed",
+		zap.String("event", "roles_changed"),
+		zap.String("employee_id", actorID.String()),
+		zap.String("user_id", userID.String()),
+		zap.Strings("roles", req.Roles),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"roles": rolesResponse(roles)})
+}
+
+func (ctrl *RoleController) respondError(c *gin.Context, err error, msg string, userID uuid.UUID) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, usecase.ErrUnknownRole), errors.Is(err, usecase.ErrRoleNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCannotChangeOwnRoles), errors.Is(err, usecase.ErrCannotGrantPermissions):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		utils.Logger.Error(msg, zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not manage roles"})
+	}
+}
+
+func rolesResponse(roles []models.Role) []gin.H {
+	response := make([]gin.H, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, gin.H{
+			"id":           role.ID,
+			"name":         role.Name,
+			"description":  role.Description,
+			"account_type": role.AccountType,
+			"permissions":  role.PermissionNames(),
+		})
+	}
+	return response
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type SessionController struct {
+	sessions *usecase.SessionService
+}
+
+func NewSessionController(sessions *usecase.SessionService) *SessionController {
+	return &SessionController{sessions: sessions}
+}
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description Lists the caller's logins that have not been revoked or expired, most recently used first. The session of the presented token is marked 'current'.
+// @Tags sessions
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Sessions in 'sessions'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/sessions [get]
+func (ctrl *SessionController) ListSessions(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	sessions, err := ctrl.sessions.ListSessions(userID)
+	if err != nil {
+		utils.Logger.Error("listing sessions failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not list sessions"})
+		return
+	}
+
+	current, _ := c.Get("session_id")
+	c.JSON(http.StatusOK, gin.H{"sessions": sessionsResponse(sessions, current)})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Ends one of the caller's sessions, e.g. a lost device: its refresh token stops working and its access tokens are rejected
+// @Tags sessions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid session ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "No active session with this ID"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/sessions/{id} [delete]
+func (ctrl *SessionController) RevokeSession(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	if err := ctrl.sessions.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, usecase.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("session revocation failed", zap.String("user_id", userID.String()), zap.String("session_id", sessionID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke session"})
+		return
+	}
+
+	utils.Logger.Info("session revoked",
+		zap.String("event", "session_revoked"),
+		zap.String("user_id", userID.String()),
+		zap.String("session_id", sessionID.String()),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// ListCustomerSessions godoc
+// @Summary List a customer's sessions
+// @Description Lists a customer's active sessions for support staff. Requires the sessions:read permission with MFA.
+// @Tags sessions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} map[string]any "Sessions in 'sessions'"
+// @Failure 400 {object} map[string]string "Invalid user ID or not a customer account"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/sessions [get]
+func (ctrl *SessionController) ListCustomerSessions(c *gin.Context) {
+	employeeIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	employeeID := employeeIDRaw.(uuid.UUID)
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	sessions, err := ctrl.sessions.ListCustomerSessions(customerID)
+	if err != nil {
+		ctrl.respondCustomerError(c, err, "listing customer sessions failed", employeeID, customerID)
+		return
+	}
+
+	utils.Logger.Info("customer sessions viewed",
+		zap.String("event", "customer_sessions_viewed"),
+		zap.String("employee_id", employeeID.String()),
+		zap.String("user_id", customerID.String()),
+	)
+	c.JSON(http.StatusOK, gin.H{"sessions": sessionsResponse(sessions, nil)})
+}
+
+// RevokeCustomerSession godoc
+// @Summary Revoke a customer's session
+// @Description Ends a customer's session, e.g. when their account is being taken over. The revocation is audited. Requires the sessions:revoke permission with MFA.
+// @Tags sessions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Customer ID"
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid ID or not a customer account"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User or active session not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/{id}/sessions/{session_id} [delete]
+func (ctrl *SessionController) RevokeCustomerSession(c *gin.Context) {
+	employeeIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	employeeID := employeeIDRaw.(uuid.UUID)
+	customerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	if err := ctrl.sessions.RevokeCustomerSession(employeeID, customerID, sessionID, c.ClientIP()); err != nil {
+		ctrl.respondCustomerError(c, err, "customer session revocation failed", employeeID, customerID)
+		return
+	}
+
+	utils.Logger.Info("customer session revoked",
+		zap.String("event", "customer_session_revoked"),
+		zap.String("employee_id", employeeID.String()),
+		zap.String("user_id", customerID.String()),
+		zap.String("session_id", sessionID.String()),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func (ctrl *SessionController) respondCustomerError(c *gin.Context, err error, msg string, employeeID, customerID uuid.UUID) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCannotManageSessions):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		utils.Logger.Error(msg, zap.String("employee_id", employeeID.String()), zap.String("user_id", customerID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not manage sessions"})
+	}
+}
+
+// sessionsResponse marks the session with ID current, if any.
+func sessionsResponse(sessions []models.Session, current any) []gin.H {
+	response := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, gin.H{
+			"id":           session.ID,
+			"device_label": session.DeviceLabel,
+			"user_agent":   session.UserAgent,
+			"ip":           session.IP,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      current == session.ID,
+		})
+	}
+	return response
+}
@@ -1,33 +1,45 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/config"
 	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/middleware"
 	"github.com/sandroJayas/user-service/models"
 	"github.com/sandroJayas/user-service/usecase"
 	"github.com/sandroJayas/user-service/utils"
 	"go.uber.org/zap"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 )
 
 type UserController struct {
-	service *usecase.UserService
+	service      *usecase.UserService
+	tokens       *usecase.TokenService
+	mfa          *usecase.MFAService
+	recovery     *usecase.RecoveryService
+	verification *usecase.EmailVerificationService
+	magicLinks   *usecase.MagicLinkService
 }
 
-func NewUserController(service *usecase.UserService) *UserController {
-	return &UserController{service: service}
+func NewUserController(service *usecase.UserService, tokens *usecase.TokenService, mfa *usecase.MFAService, recovery *usecase.RecoveryService, verification *usecase.EmailVerificationService, magicLinks *usecase.MagicLinkService) *UserController {
+	return &UserController{service: service, tokens: tokens, mfa: mfa, recovery: recovery, verification: verification, magicLinks: magicLinks}
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Creates a new user account with email and password
+// @Description Creates a new user account with email and password and emails a link to verify the address. If the email is already registered the response looks the same and the account's owner is emailed instead.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param registerRequest body dto.RegisterRequest true "Registration data"
 // @Success 201 {object} map[string]any "Created user object in 'user' field"
-// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 400 {object} map[string]any "Invalid input, or a rejected password with violations in 'fields'"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/register [post]
 func (ctrl *UserController) Register(c *gin.Context) {
@@ -43,25 +55,52 @@ func (ctrl *UserController) Register(c *gin.Context) {
 		Password:    registerRequest.Password,
 		AccountType: models.AccountTypeCustomer,
 	}
-	if err := ctrl.service.Register(&user); err != nil {
+	err := ctrl.service.Register(&user)
+	if errors.Is(err, usecase.ErrEmailTaken) {
+		// Answer exactly as for a new account so the response doesn't reveal
+		// that the email is registered; the owner hears about it by email.
+		utils.Logger.Info("registration for existing email", zap.String("event", "registration_email_taken"))
+		if err := ctrl.verification.SendAccountExists(&user); err != nil {
+			utils.Logger.Error("failed to send account exists email", zap.Error(err))
+		}
+		c.JSON(http.StatusCreated, gin.H{"user": unsavedUser(user)})
+		return
+	}
+	if err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
 		utils.Logger.Error("user registration failed", zap.String("email", registerRequest.Email), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create account"})
 		return
 	}
+	ctrl.sendVerification(&user)
 
 	c.JSON(http.StatusCreated, gin.H{"user": user})
 }
 
+// unsavedUser fills in what creating user would have, for responses that
+// must look like a successful registration.
+func unsavedUser(user models.User) models.User {
+	now := time.Now().Local()
+	user.ID = uuid.New()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return user
+}
+
 // Login godoc
 // @Summary Log in a user
-// @Description Authenticates user with email and password, returns JWT token
+// @Description Authenticates user with email and password, returns a short-lived JWT and a refresh token. When the user has two-factor authentication enabled, returns 'mfa_required' and an 'mfa_token' to complete at /users/login/mfa instead.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param loginRequest body dto.LoginRequest true "Login data"
-// @Success 200 {object} map[string]any "JWT token in 'token' field"
+// @Success 200 {object} map[string]any "JWT in 'token', refresh token in 'refresh_token', with their expiries; or an MFA challenge"
 // @Failure 400 {object} map[string]string "Invalid input"
 // @Failure 401 {object} map[string]string "Invalid credentials"
+// @Failure 403 {object} map[string]string "Email not verified, when LOGIN_REQUIRES_VERIFIED_EMAIL is set, or password reset required after a reported login"
+// @Failure 429 {object} map[string]string "Account temporarily locked after failed attempts; see Retry-After"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/login [post]
 func (ctrl *UserController) Login(c *gin.Context) {
@@ -72,22 +111,345 @@ func (ctrl *UserController) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.service.Login(loginRequest.Email, loginRequest.Password)
+	user, err := ctrl.service.Login(loginRequest.Email, loginRequest.Password, clientInfo(c))
+	if respondLoginRefused(c, err) {
+		return
+	}
 	if err != nil {
 		utils.Logger.Warn("login failed", zap.String("email", loginRequest.Email), zap.Error(err))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
+	if respondEmailNotVerified(c, user) {
+		return
+	}
+
+	ctrl.completeLogin(c, user, []string{utils.AMRPassword})
+}
+
+// SendMagicLink godoc
+// @Summary Email a login link
+// @Description Emails a short-lived, single-use login link if an account exists for the address. The response is the same whether or not it does.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.MagicLinkRequest true "Account email"
+// @Success 202 {object} map[string]string "Confirmation message"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/login/magic-link [post]
+func (ctrl *UserController) SendMagicLink(c *gin.Context) {
+	var req dto.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ctrl.magicLinks.Send(req.Email); err != nil {
+		utils.Logger.Error("magic link request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not send login link"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for this email, a login link has been sent"})
+}
+
+// ConsumeMagicLink godoc
+// @Summary Log in with an emailed link
+// @Description Exchanges the token from a login link for a JWT and a refresh token. Users with two-factor authentication get 'mfa_required' and an 'mfa_token' instead, like /users/login. The account lockout, the password reset required after a reported login, and new device alerts apply as for password logins.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ConsumeMagicLinkRequest true "Token from the login link"
+// @Success 200 {object} map[string]any "JWT in 'token', refresh token in 'refresh_token', with their expiries; or an MFA challenge"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid or expired link"
+// @Failure 403 {object} map[string]string "Password reset required after a reported login"
+// @Failure 429 {object} map[string]string "Account temporarily locked after failed attempts; see Retry-After"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/login/magic-link/consume [post]
+func (ctrl *UserController) ConsumeMagicLink(c *gin.Context) {
+	var req dto.ConsumeMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.magicLinks.Consume(req.Token)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidMagicLink) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("magic link login failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log in"})
+		return
+	}
+	if err := ctrl.service.AcceptLogin(user, clientInfo(c)); err != nil {
+		if respondLoginRefused(c, err) {
+			return
+		}
+		utils.Logger.Error("magic link login failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log in"})
+		return
+	}
+
+	ctrl.completeLogin(c, user, []string{utils.AMREmailLink})
+}
+
+// respondLoginRefused answers for the errors that refuse a login to a user
+// who did prove who they are, and reports whether it did.
+func respondLoginRefused(c *gin.Context, err error) bool {
+	var locked *usecase.AccountLockedError
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+	case errors.Is(err, usecase.ErrPasswordResetRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Password reset required, check your email for a reset link"})
+	default:
+		return false
+	}
+	return true
+}
+
+// respondEmailNotVerified answers 403 if LOGIN_REQUIRES_VERIFIED_EMAIL is
+// set and the user hasn't verified their email, and reports whether it did.
+func respondEmailNotVerified(c *gin.Context, user *models.User) bool {
+	if !config.AppConfig.LoginRequiresVerifiedEmail || user.EmailVerifiedAt != nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+	return true
+}
+
+// completeLogin finishes a successful first factor: users with two-factor
+// authentication get an MFA challenge for /users/login/mfa, everyone else
+// gets their tokens.
+func (ctrl *UserController) completeLogin(c *gin.Context, user *models.User, amr []string) {
+	mfaEnabled, err := ctrl.mfa.IsEnabled(user.ID)
+	if err != nil {
+		utils.Logger.Error("mfa lookup failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+	if mfaEnabled {
+		challenge, _, err := utils.GenerateMFAChallenge(user.ID, amr, config.AppConfig.MFAChallengeTTL)
+		if err != nil {
+			utils.Logger.Error("mfa challenge generation failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": challenge})
+		return
+	}
+
+	pair, err := ctrl.tokens.IssueTokens(user, amr, clientInfo(c))
+	if err != nil {
+		utils.Logger.Error("token generation failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPairResponse(pair))
+}
+
+// LoginMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchanges the 'mfa_token' returned by a first login step and a code from the authenticator app for a JWT and a refresh token. The 'mfa_token' can only be exchanged once, and wrong codes count towards the account lockout.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.LoginMFARequest true "MFA challenge and code"
+// @Success 200 {object} map[string]any "JWT in 'token', refresh token in 'refresh_token', with their expiries"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid, expired or used challenge, or invalid code"
+// @Failure 429 {object} map[string]string "Account temporarily locked after failed attempts; see Retry-After"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/login/mfa [post]
+func (ctrl *UserController) LoginMFA(c *gin.Context) {
+	var req dto.LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	token, err := utils.GenerateToken(user.ID, user.AccountType)
+	challenge, err := utils.ParsePurposeToken(req.MFAToken, utils.TokenUseMFA)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+	userID := challenge.UserID
+
+	err = ctrl.service.VerifyCode(userID, func() error {
+		return ctrl.mfa.VerifyTOTP(userID, req.Code)
+	})
+	if respondLoginRefused(c, err) {
+		return
+	}
+	if errors.Is(err, usecase.ErrInvalidMFACode) {
+		utils.Logger.Warn("mfa login failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
+		return
+	}
+	if err != nil {
+		utils.Logger.Error("mfa login failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not verify code"})
+		return
+	}
+
+	if err := ctrl.tokens.ConsumeOnce(userID, challenge.JTI, challenge.ExpiresAt); err != nil {
+		if errors.Is(err, usecase.ErrTokenAlreadyUsed) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+			return
+		}
+		utils.Logger.Error("mfa token consumption failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	user, err := ctrl.service.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+
+	amr := challenge.AMR
+	if len(amr) == 0 {
+		amr = []string{utils.AMRPassword}
+	}
+	pair, err := ctrl.tokens.IssueTokens(user, append(amr, utils.AMROTP), clientInfo(c))
 	if err != nil {
 		utils.Logger.Error("token generation failed", zap.String("user_id", user.ID.String()), zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPairResponse(pair))
+}
+
+// RefreshToken godoc
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new JWT and a new refresh token. The presented refresh token is rotated and cannot be used again; reusing it revokes every token issued from the same login.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param refreshRequest body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} map[string]any "JWT in 'token', refresh token in 'refresh_token', with their expiries"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Invalid or reused refresh token"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/token/refresh [post]
+func (ctrl *UserController) RefreshToken(c *gin.Context) {
+	var refreshRequest dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&refreshRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := ctrl.tokens.Refresh(refreshRequest.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidRefreshToken) || errors.Is(err, usecase.ErrRefreshTokenReused) {
+			utils.Logger.Warn("token refresh rejected", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		utils.Logger.Error("token refresh failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPairResponse(pair))
+}
+
+// Logout godoc
+// @Summary Log out the current session
+// @Description Revokes the presented JWT immediately and ends its session, so every refresh token issued from the same login is revoked as well. For tokens issued before sessions were recorded, supply the refresh token to revoke them.
+// @Tags auth
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param logoutRequest body dto.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} map[string]string "Logout confirmation"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/logout [post]
+func (ctrl *UserController) Logout(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	// The body is optional; a missing or malformed one only skips refresh revocation.
+	var logoutRequest dto.LogoutRequest
+	_ = c.ShouldBindJSON(&logoutRequest)
+
+	sessionID, _ := c.Get("session_id")
+	sid, _ := sessionID.(uuid.UUID)
+	if err := ctrl.tokens.Logout(userID, sid, c.GetString("jti"), c.GetTime("token_expires_at"), logoutRequest.RefreshToken); err != nil {
+		utils.Logger.Error("logout failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revokes every JWT and refresh token issued to the current user
+// @Tags auth
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]string "Logout confirmation"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/logout-all [post]
+func (ctrl *UserController) LogoutAll(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
 
+	if err := ctrl.tokens.RevokeAllForUser(userID); err != nil {
+		utils.Logger.Error("logout all failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+// JWKS godoc
+// @Summary Public signing keys
+// @Description Publishes the public keys that verify tokens issued by this service, selected by the token's kid header
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} utils.JWKSet "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (ctrl *UserController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.Keys.JWKS())
+}
+
+// clientInfo describes the device making the request, for the session
+// record.
+func clientInfo(c *gin.Context) usecase.ClientInfo {
+	return usecase.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+func tokenPairResponse(pair *usecase.TokenPair) gin.H {
+	return gin.H{
+		"token":                    pair.AccessToken,
+		"token_expires_at":         pair.AccessExpiresAt,
+		"refresh_token":            pair.RefreshToken,
+		"refresh_token_expires_at": pair.RefreshExpiresAt,
+	}
 }
 
 // Me godoc
@@ -96,7 +458,7 @@ func (ctrl *UserController) Login(c *gin.Context) {
 // @Tags users
 // @Security BearerAuth
 // @Produce  json
-// @Success 200 {object} map[string]any "User object in 'user' field"
+// @Success 200 {object} map[string]any "User object in 'user' field and unused recovery code count in 'recovery_codes_remaining'"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/me [get]
@@ -114,48 +476,69 @@ func (ctrl *UserController) Me(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	remaining, err := ctrl.recovery.Remaining(userID)
+	if err != nil {
+		utils.Logger.Error("recovery code count failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "recovery_codes_remaining": remaining})
 }
 
 // SpecialEmployeeEndpoint godoc
 // @Summary Special command for Sort employees
-// @Description This route is only accessible by Sort employees (account_type=employee)
+// @Description This route is only accessible to staff with the employee:commands permission
 // @Tags users
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} map[string]string "Success message"
+// @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 403 {object} map[string]string "Forbidden"
 // @Router /users/special [post]
 func (ctrl *UserController) SpecialEmployeeEndpoint(c *gin.Context) {
-	userID := c.GetString("user_id")
-	accountType := c.GetString("account_type")
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
 
 	utils.Logger.Info("special employee endpoint triggered",
-		zap.String("user_id", userID),
-		zap.String("account_type", accountType),
+		zap.String("user_id", userID.String()),
+		zap.String("permission", models.PermissionEmployeeCommands),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Sort employee command executed successfully",
 		"user_id": userID,
-		"role":    accountType,
+		"role":    c.GetString("account_type"),
 	})
 }
 
 // CreateEmployee godoc
 // @Summary Create a Sort employee account
-// @Description Allows creation of a user with employee privileges
+// @Description Creates an employee account holding the requested roles, or no roles when none are given. Roles must be employee roles, and callers can only grant permissions they hold themselves. Requires the roles:assign permission with MFA.
 // @Tags admin
 // @Security BearerAuth
 // @Accept  json
 // @Produce  json
 // @Param request body dto.CreateEmployeeRequest true "Employee creation data"
 // @Success 201 {object} map[string]any "Created employee in 'user' field"
-// @Failure 400 {object} map[string]string "Invalid input"
-// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 400 {object} map[string]any "Invalid input, an unknown or non-employee role, or a rejected password with violations in 'fields'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden, or granting a permission you lack"
+// @Failure 409 {object} map[string]string "Email already registered"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/create-employee [post]
 func (ctrl *UserController) CreateEmployee(c *gin.Context) {
+	actorIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	actorID := actorIDRaw.(uuid.UUID)
+
 	var req dto.CreateEmployeeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -163,22 +546,42 @@ func (ctrl *UserController) CreateEmployee(c *gin.Context) {
 	}
 
 	user := models.User{
-		Email:       req.Email,
-		Password:    req.Password,
-		AccountType: "employee",
+		Email:    req.Email,
+		Password: req.Password,
 	}
 
-	if err := ctrl.service.Register(&user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := ctrl.service.CreateEmployee(c.GetStringSlice("permissions"), &user, req.Roles); err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, usecase.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrUnknownRole), errors.Is(err, usecase.ErrRoleNotAllowed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, usecase.ErrCannotGrantPermissions):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			utils.Logger.Error("employee creation failed", zap.String("email", req.Email), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create account"})
+		}
 		return
 	}
+	ctrl.sendVerification(&user)
 
+	utils.Logger.Info("employee created",
+		zap.String("event", "employee_created"),
+		zap.String("employee_id", actorID.String()),
+		zap.String("user_id", user.ID.String()),
+		zap.Strings("roles", req.Roles),
+		zap.String("ip", c.ClientIP()),
+	)
 	c.JSON(http.StatusCreated, gin.H{"user": user})
 }
 
 // UpdateProfile godoc
 // @Summary Update user's profile
-// @Description Updates the logged-in user's profile fields
+// @Description Updates the logged-in user's profile fields. Changing the payment method requires an auth_time within RECENT_AUTH_MAX_AGE; see /users/reauth.
 // @Tags users
 // @Security BearerAuth
 // @Accept  json
@@ -186,7 +589,7 @@ func (ctrl *UserController) CreateEmployee(c *gin.Context) {
 // @Param updateRequest body dto.UpdateProfileRequest true "Profile update data"
 // @Success 200 {object} map[string]any "Updated user in 'user' field"
 // @Failure 400 {object} map[string]string "Invalid input"
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 401 {object} map[string]string "Unauthorized, or a payment method change without recent authentication"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/profile [put]
 func (ctrl *UserController) UpdateProfile(c *gin.Context) {
@@ -202,6 +605,19 @@ func (ctrl *UserController) UpdateProfile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Only payment method changes are sensitive enough to need a recent
+	// authentication.
+	current, err := ctrl.service.GetUserByID(userID)
+	if err != nil {
+		utils.Logger.Error("profile update failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update user"})
+		return
+	}
+	maxAge := config.AppConfig.RecentAuthMaxAge
+	if updateRequest.PaymentMethodID != current.PaymentMethodID && !middleware.AuthenticatedWithin(c, maxAge) {
+		middleware.AbortReauthRequired(c, maxAge)
+		return
+	}
 	user := models.User{
 		FirstName:       updateRequest.FirstName,
 		LastName:        updateRequest.LastName,
@@ -223,14 +639,169 @@ func (ctrl *UserController) UpdateProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user": updatedUser})
 }
 
+// Reauth godoc
+// @Summary Re-authenticate for a sensitive operation
+// @Description Checks the password, or a code from the authenticator app, of the signed-in user and returns a fresh access token for the same session whose auth_time is now. Routes that change credentials, payment details or delete the account require an auth_time within RECENT_AUTH_MAX_AGE. Wrong passwords and codes count towards the login lockout.
+// @Tags auth
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ReauthRequest true "Password or authenticator code"
+// @Success 200 {object} map[string]any "JWT in 'token' and its expiry in 'token_expires_at'"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Wrong password or code, or password reset required"
+// @Failure 429 {object} map[string]string "Too many wrong passwords or codes"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/reauth [post]
+func (ctrl *UserController) Reauth(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.ReauthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var (
+		user   *models.User
+		err    error
+		method string
+	)
+	if req.Password != "" {
+		method = utils.AMRPassword
+		user, err = ctrl.service.Reauthenticate(userID, req.Password)
+	} else {
+		method = utils.AMROTP
+		err = ctrl.service.VerifyCode(userID, func() error {
+			return ctrl.mfa.VerifyTOTP(userID, req.Code)
+		})
+		if err == nil {
+			user, err = ctrl.service.GetUserByID(userID)
+		}
+	}
+	if respondLoginRefused(c, err) {
+		return
+	}
+	if errors.Is(err, usecase.ErrIncorrectPassword) || errors.Is(err, usecase.ErrInvalidMFACode) {
+		utils.Logger.Warn("re-authentication rejected",
+			zap.String("event", "reauth_failed"),
+			zap.String("user_id", userID.String()),
+			zap.String("method", method),
+			zap.String("ip", c.ClientIP()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		utils.Logger.Error("re-authentication failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not re-authenticate"})
+		return
+	}
+
+	amr := c.GetStringSlice("amr")
+	if !slices.Contains(amr, method) {
+		amr = append(amr, method)
+	}
+	sessionID, _ := c.Get("session_id")
+	sid, _ := sessionID.(uuid.UUID)
+	token, expiresAt, err := ctrl.tokens.IssueReauthenticatedToken(user, amr, sid)
+	if err != nil {
+		utils.Logger.Error("token generation failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	utils.Logger.Info("re-authenticated",
+		zap.String("event", "reauth"),
+		zap.String("user_id", userID.String()),
+		zap.String("method", method),
+		zap.String("ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{"token": token, "token_expires_at": expiresAt})
+}
+
+// ChangePassword godoc
+// @Summary Change the current user's password
+// @Description Replaces the password after checking the current one. Every token issued before the change is revoked; the response carries a new token pair for the caller.
+// @Tags users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} map[string]any "New token pair"
+// @Failure 400 {object} map[string]any "Invalid input, or a rejected password with violations in 'fields'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Current password is incorrect"
+// @Failure 429 {object} map[string]string "Too many wrong passwords"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/me/password [put]
+func (ctrl *UserController) ChangePassword(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.service.ChangePassword(userID, req.CurrentPassword, req.NewPassword)
+	if respondLoginRefused(c, err) {
+		return
+	}
+	if errors.Is(err, usecase.ErrIncorrectPassword) {
+		utils.Logger.Warn("password change rejected",
+			zap.String("event", "password_change_failed"),
+			zap.String("user_id", userID.String()),
+			zap.String("ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if err != nil {
+		if respondPasswordRejected(c, err) {
+			return
+		}
+		utils.Logger.Error("password change failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not change password"})
+		return
+	}
+
+	utils.Logger.Info("password changed",
+		zap.String("event", "password_changed"),
+		zap.String("user_id", userID.String()),
+		zap.String("ip", c.ClientIP()),
+	)
+
+	pair, err := ctrl.tokens.IssueTokens(user, c.GetStringSlice("amr"), clientInfo(c))
+	if err != nil {
+		// The password did change; the user only has to log in again.
+		utils.Logger.Error("token issuance after password change failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed, please log in again"})
+		return
+	}
+	c.JSON(http.StatusOK, tokenPairResponse(pair))
+}
+
 // DeleteUser godoc
 // @Summary Soft-delete the current user
-// @Description Marks the user as deleted (is_deleted = true)
+// @Description Marks the user as deleted (is_deleted = true). Requires an auth_time within RECENT_AUTH_MAX_AGE; see /users/reauth.
 // @Tags users
 // @Security BearerAuth
 // @Produce  json
 // @Success 200 {object} map[string]any "Deletion confirmation"
-// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 401 {object} map[string]string "Unauthorized, or no recent authentication"
 // @Failure 500 {object} map[string]string "Server error"
 // @Router /users/delete [delete]
 func (ctrl *UserController) DeleteUser(c *gin.Context) {
@@ -249,3 +820,97 @@ func (ctrl *UserController) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 }
+
+// GetLockout godoc
+// @Summary Get a user's login lockout state
+// @Description Shows consecutive failed password logins and until when the account is locked, for support staff
+// @Tags admin
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]any "Failed attempts, last failure, 'locked_until' and whether the account is 'locked' now"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/lockouts/{id} [get]
+func (ctrl *UserController) GetLockout(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	state, err := ctrl.service.LockoutState(userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		utils.Logger.Error("lockout lookup failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch lockout state"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":         state.UserID,
+		"failed_attempts": state.FailedAttempts,
+		"last_failed_at":  state.LastFailedAt,
+		"locked_until":    state.LockedUntil,
+		"locked":          state.LockedUntil != nil && state.LockedUntil.After(time.Now()),
+	})
+}
+
+// GetUserForService godoc
+// @Summary Look up a user as a service
+// @Description Returns a user's public account fields to a machine client holding the users:read scope
+// @Tags internal
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]any "User in 'user' field"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Not a service token or missing scope"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /internal/users/{id} [get]
+func (ctrl *UserController) GetUserForService(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	user, err := ctrl.service.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		utils.Logger.Error("service user lookup failed", zap.String("user_id", userID.String()), zap.String("client_id", c.GetString("client_id")), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch user"})
+		return
+	}
+
+	// Services get account fields only, never credentials.
+	c.JSON(http.StatusOK, gin.H{"user": gin.H{
+		"id":                user.ID,
+		"email":             user.Email,
+		"account_type":      user.AccountType,
+		"email_verified_at": user.EmailVerifiedAt,
+		"first_name":        user.FirstName,
+		"last_name":         user.LastName,
+		"created_at":        user.CreatedAt,
+	}})
+}
+
+// sendVerification emails a verification link to a newly created user. A
+// failure is only logged: the account exists and the user can ask for a new
+// link.
+func (ctrl *UserController) sendVerification(user *models.User) {
+	if err := ctrl.verification.SendVerification(user); err != nil {
+		utils.Logger.Error("failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/dto"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type WebAuthnController struct {
+	webauthn *usecase.WebAuthnService
+	users    *usecase.UserService
+	tokens   *usecase.TokenService
+}
+
+func NewWebAuthnController(webauthn *usecase.WebAuthnService, users *usecase.UserService, tokens *usecase.TokenService) *WebAuthnController {
+	return &WebAuthnController{webauthn: webauthn, users: users, tokens: tokens}
+}
+
+// BeginRegistration godoc
+// @Summary Start passkey registration
+// @Description Returns the options for navigator.credentials.create and a 'session_id' to send back with the authenticator's response. Requires a verified email address.
+// @Tags webauthn
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} map[string]any "Creation options in 'options' and the ceremony id in 'session_id'"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Email not verified"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/webauthn/register/begin [post]
+func (ctrl *WebAuthnController) BeginRegistration(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	options, sessionID, err := ctrl.webauthn.BeginRegistration(userID)
+	if err != nil {
+		utils.Logger.Error("webauthn registration start failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "options": options})
+}
+
+// FinishRegistration godoc
+// @Summary Complete passkey registration
+// @Description Verifies the authenticator's attestation and stores the new passkey
+// @Tags webauthn
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param request body dto.WebAuthnRegisterFinishRequest true "Ceremony id and the PublicKeyCredential from the browser"
+// @Success 201 {object} models.WebAuthnCredential "Registered passkey"
+// @Failure 400 {object} map[string]string "Invalid input or attestation"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Email not verified"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/webauthn/register/finish [post]
+func (ctrl *WebAuthnController) FinishRegistration(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	userID := userIDRaw.(uuid.UUID)
+
+	var req dto.WebAuthnRegisterFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, err := uuid.Parse(req.SessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	credential, err := ctrl.webauthn.FinishRegistration(userID, sessionID, req.Name, req.Credential)
+	if err != nil {
+		if errors.Is(err, usecase.ErrWebAuthnSessionNotFound) || errors.Is(err, usecase.ErrWebAuthnFailed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		utils.Logger.Error("webauthn registration failed", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not register passkey"})
+		return
+	}
+
+	utils.Logger.Info("passkey registered", zap.String("user_id", userID.String()), zap.String("credential", credential.ID.String()))
+	c.JSON(http.StatusCreated, credential)
+}
+
+// BeginLogin godoc
+// @Summary Start a passkey login
+// @Description Returns the options for navigator.credentials.get and a 'session_id'. No username is needed; the authenticator chooses the passkey.
+// @Tags webauthn
+// @Produce  json
+// @Success 200 {object} map[string]any "Request options in 'options' and the ceremony id in 'session_id'"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/webauthn/login/begin [post]
+func (ctrl *WebAuthnController) BeginLogin(c *gin.Context) {
+	options, sessionID, err := ctrl.webauthn.BeginLogin()
+	if err != nil {
+		utils.Logger.Error("webauthn login start failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "options": options})
+}
+
+// FinishLogin godoc
+// @Summary Complete a passkey login
+// @Description Verifies the authenticator's assertion and returns a JWT and a refresh token. The account lockout, LOGIN_REQUIRES_VERIFIED_EMAIL, the password reset required after a reported login, and new device alerts apply as for password logins.
+// @Tags webauthn
+// @Accept  json
+// @Produce  json
+// @Param request body dto.WebAuthnLoginFinishRequest true "Ceremony id and the PublicKeyCredential from the browser"
+// @Success 200 {object} map[string]any "JWT in 'token', refresh token in 'refresh_token', with their expiries"
+// @Failure 400 {object} map[string]string "Invalid input"
+// @Failure 401 {object} map[string]string "Assertion rejected"
+// @Failure 403 {object} map[string]string "Email not verified, when LOGIN_REQUIRES_VERIFIED_EMAIL is set, or password reset required after a reported login"
+// @Failure 429 {object} map[string]string "Account temporarily locked after failed attempts; see Retry-After"
+// @Failure 500 {object} map[string]string "Server error"
+// @Router /users/webauthn/login/finish [post]
+func (ctrl *WebAuthnController) FinishLogin(c *gin.Context) {
+	var req dto.WebAuthnLoginFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, err := uuid.Parse(req.SessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	user, err := ctrl.webauthn.FinishLogin(sessionID, req.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrWebAuthnSessionNotFound),
+			errors.Is(err, usecase.ErrWebAuthnFailed),
+			errors.Is(err, usecase.ErrWebAuthnCloneDetected):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey login failed"})
+		default:
+			utils.Logger.Error("webauthn login failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		}
+		return
+	}
+	if respondEmailNotVerified(c, user) {
+		return
+	}
+	if err := ctrl.users.AcceptLogin(user, clientInfo(c)); err != nil {
+		if respondLoginRefused(c, err) {
+			return
+		}
+		utils.Logger.Error("webauthn login failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not complete login"})
+		return
+	}
+
+	pair, err := ctrl.tokens.IssueTokens(user, []string{utils.AMRHardwareKey}, clientInfo(c))
+	if err != nil {
+		utils.Logger.Error("token generation failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPairResponse(pair))
+}
@@ -0,0 +1,20 @@
+package notification
+
+import "time"
+
+// LoginAlert tells a user their account was accessed from a device it had
+// not been used from before.
+type LoginAlert struct {
+	To          string
+	DeviceLabel string
+	IP          string
+	At          time.Time
+	// DisownURL is the "this wasn't me" link, which signs the user out
+	// everywhere and requires a password reset.
+	DisownURL string
+}
+
+// LoginNotifier delivers login alerts, e.g. by email or push notification.
+type LoginNotifier interface {
+	NotifyNewLogin(alert LoginAlert) error
+}
@@ -0,0 +1,12 @@
+package notification
+
+// SMS is a plain text message to a phone number.
+type SMS struct {
+	To   string
+	Body string
+}
+
+// SMSSender delivers text messages such as phone verification codes.
+type SMSSender interface {
+	Send(msg SMS) error
+}
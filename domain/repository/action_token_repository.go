@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type ActionTokenRepository interface {
+	Create(token *models.ActionToken) error
+	// Consume marks an unused, unexpired token as used and returns it. It
+	// returns gorm.ErrRecordNotFound if no such token exists.
+	Consume(tokenHash, purpose string) (*models.ActionToken, error)
+	// FindValid returns an unused, unexpired token without consuming it, or
+	// gorm.ErrRecordNotFound.
+	FindValid(tokenHash, purpose string) (*models.ActionToken, error)
+	// FindLatest returns the user's most recently issued token of the given
+	// purpose, used or not.
+	FindLatest(userID uuid.UUID, purpose string) (*models.ActionToken, error)
+	// InvalidateForUser burns every outstanding token of the given purpose.
+	InvalidateForUser(userID uuid.UUID, purpose string) error
+}
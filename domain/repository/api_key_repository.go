@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	// FindActiveByHash returns gorm.ErrRecordNotFound for unknown, revoked
+	// or expired keys.
+	FindActiveByHash(hash string, now time.Time) (*models.APIKey, error)
+	ListForUser(userID uuid.UUID) ([]models.APIKey, error)
+	// Revoke reports false if the user has no active key with the ID.
+	Revoke(userID, id uuid.UUID, at time.Time) (bool, error)
+	TouchLastUsed(id uuid.UUID, at time.Time, ip string) error
+}
@@ -0,0 +1,9 @@
+package repository
+
+import (
+	"github.com/sandroJayas/user-service/models"
+)
+
+type AuditEventRepository interface {
+	Create(event *models.AuditEvent) error
+}
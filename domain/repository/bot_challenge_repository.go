@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type BotChallengeRepository interface {
+	// Spend reports false when the challenge was already spent.
+	Spend(challenge *models.SpentBotChallenge) (bool, error)
+	PurgeExpired(now time.Time) error
+}
@@ -0,0 +1,9 @@
+package repository
+
+// BreachedPasswordRepository answers k-anonymity range queries like the
+// Pwned Passwords API: given the first five hex characters of a password's
+// SHA-1, it returns the remaining 35 characters of every breached hash with
+// that prefix, upper case.
+type BreachedPasswordRepository interface {
+	Range(prefix string) ([]string, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type KnownDeviceRepository interface {
+	// Touch records a login from a known device and reports false if the
+	// user has no device with the fingerprint.
+	Touch(userID uuid.UUID, fingerprint string, at time.Time) (bool, error)
+	// Create reports false if the device was recorded concurrently.
+	Create(device *models.KnownDevice) (bool, error)
+	HasAny(userID uuid.UUID) (bool, error)
+	DeleteForUser(userID uuid.UUID) error
+}
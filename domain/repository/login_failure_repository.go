@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type LoginFailureRepository interface {
+	// Find returns gorm.ErrRecordNotFound for users without failures.
+	Find(userID uuid.UUID) (*models.LoginFailure, error)
+	// RecordFailure atomically increments the user's failure count and
+	// returns the new count.
+	RecordFailure(userID uuid.UUID, at time.Time) (int, error)
+	SetLockedUntil(userID uuid.UUID, until time.Time) error
+	Reset(userID uuid.UUID) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type MFARepository interface {
+	// FindTOTP returns gorm.ErrRecordNotFound when the user never enrolled.
+	FindTOTP(userID uuid.UUID) (*models.TOTPFactor, error)
+	SaveTOTP(factor *models.TOTPFactor) error
+	// MarkTOTPStepUsed records step as used if it is newer than the last
+	// accepted one and reports whether it was.
+	MarkTOTPStepUsed(userID uuid.UUID, step int64) (bool, error)
+}
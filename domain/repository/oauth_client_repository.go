@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type OAuthClientRepository interface {
+	Create(client *models.OAuthClient) error
+	// FindActive returns gorm.ErrRecordNotFound for unknown or revoked
+	// clients.
+	FindActive(id uuid.UUID) (*models.OAuthClient, error)
+	List() ([]models.OAuthClient, error)
+	// Revoke reports false if there was no active client with the ID.
+	Revoke(id uuid.UUID, at time.Time) (bool, error)
+	TouchLastUsed(id uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type PhoneVerificationRepository interface {
+	// Replace stores a new verification and retires the user's earlier ones.
+	Replace(verification *models.PhoneVerification) error
+	// FindLatest returns gorm.ErrRecordNotFound if the user never requested
+	// a code.
+	FindLatest(userID uuid.UUID) (*models.PhoneVerification, error)
+	// ClaimAttempt counts a guess against an unused verification. It
+	// reports false once maxAttempts guesses have been made.
+	ClaimAttempt(id uuid.UUID, maxAttempts int) (bool, error)
+	// MarkUsed reports false if the verification had already been used.
+	MarkUsed(id uuid.UUID, at time.Time) (bool, error)
+}
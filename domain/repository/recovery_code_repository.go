@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type RecoveryCodeRepository interface {
+	// ReplaceForUser deletes the user's existing codes and stores the new set.
+	ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCode) error
+	FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.RecoveryCode, error)
+	CountUnused(userID uuid.UUID) (int64, error)
+	// MarkUsed reports false when the code had already been used.
+	MarkUsed(id uuid.UUID) (bool, error)
+}
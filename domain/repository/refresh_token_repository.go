@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	FindByHash(hash string) (*models.RefreshToken, error)
+	// MarkRotated revokes an active token and records its replacement. It
+	// reports false when the token had already been revoked.
+	MarkRotated(id uuid.UUID, replacedBy uuid.UUID) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeAllForUser(userID uuid.UUID) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type RoleRepository interface {
+	// List returns every role with its permissions, by name.
+	List() ([]models.Role, error)
+	// FindByNames returns the roles with the given names that exist.
+	FindByNames(names []string) ([]models.Role, error)
+	ListForUser(userID uuid.UUID) ([]models.Role, error)
+	// PermissionsForUser returns the distinct permissions granted by the
+	// user's roles, sorted.
+	PermissionsForUser(userID uuid.UUID) ([]string, error)
+	// ReplaceForUser makes roleIDs the user's only roles.
+	ReplaceForUser(userID uuid.UUID, roleIDs []uuid.UUID) error
+}
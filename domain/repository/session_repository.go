@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByID(id uuid.UUID) (*models.Session, error)
+	// ListActive returns the user's sessions that are neither revoked nor
+	// expired, most recently seen first.
+	ListActive(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	// Touch records activity on a session and extends it to expiresAt.
+	Touch(id uuid.UUID, at time.Time, ip, userAgent string, expiresAt time.Time) error
+	// Revoke reports false if the user has no unrevoked session with the ID.
+	Revoke(userID, id uuid.UUID, at time.Time) (bool, error)
+	RevokeAllForUser(userID uuid.UUID, at time.Time) error
+}
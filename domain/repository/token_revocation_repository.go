@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"time"
+)
+
+type TokenRevocationRepository interface {
+	// RevokeToken reports false when the jti was already revoked.
+	RevokeToken(token *models.RevokedToken) (bool, error)
+	IsTokenRevoked(jti string) (bool, error)
+	RevokeUserTokens(userID uuid.UUID, before time.Time) error
+	// UserRevokedBefore returns the zero time when the user has no cutoff.
+	UserRevokedBefore(userID uuid.UUID) (time.Time, error)
+	PurgeExpired(now time.Time) error
+}
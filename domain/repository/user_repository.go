@@ -6,9 +6,13 @@ import (
 )
 
 type UserRepository interface {
-	CreateUser(user *models.User) error
+	// CreateUser stores the user and gives them the named roles in one
+	// transaction. It returns gorm.ErrDuplicatedKey if the email is taken and
+	// gorm.ErrRecordNotFound if a role does not exist.
+	CreateUser(user *models.User, roleNames ...string) error
 	FindByEmail(email string) (*models.User, error)
 	FindByID(id uuid.UUID, user *models.User) error
 	Save(user *models.User) error
+	UpdatePassword(id uuid.UUID, hash string) error
 	SoftDelete(id uuid.UUID) error
 }
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+)
+
+type WebAuthnRepository interface {
+	CreateCredential(credential *models.WebAuthnCredential) error
+	FindCredentialsByUser(userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	SaveCredential(credential *models.WebAuthnCredential) error
+
+	CreateSession(session *models.WebAuthnSession) error
+	// TakeSession deletes and returns an unexpired session so that every
+	// ceremony can only be finished once.
+	TakeSession(id uuid.UUID, ceremony string) (*models.WebAuthnSession, error)
+	PurgeExpiredSessions() error
+}
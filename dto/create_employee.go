@@ -1,6 +1,9 @@
 package dto
 
+// CreateEmployeeRequest creates an employee holding Roles. Without roles the
+// employee has no permissions until roles are assigned.
 type CreateEmployeeRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password" binding:"required"`
+	Roles    []string `json:"roles" binding:"omitempty,dive,required"`
 }
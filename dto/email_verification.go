@@ -0,0 +1,5 @@
+package dto
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type ImpersonateRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	// Reason is kept in the audit trail, e.g. a support ticket reference.
+	Reason string `json:"reason" binding:"required"`
+}
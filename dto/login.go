@@ -4,3 +4,14 @@ type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 }
+
+// ReauthRequest proves the user's identity again with either their
+// password or a code from their authenticator app.
+type ReauthRequest struct {
+	Password string `json:"password" binding:"required_without=Code"`
+	Code     string `json:"code" binding:"required_without=Password"`
+}
+
+type DisownLoginRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
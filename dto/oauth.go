@@ -0,0 +1,6 @@
+package dto
+
+type CreateOAuthClientRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+type RecoverAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// RecoveryResetRequest sets a new password and, for users who lost access to
+// their mailbox, optionally a new email address.
+type RecoveryResetRequest struct {
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email"`
+}
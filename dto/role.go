@@ -0,0 +1,6 @@
+package dto
+
+// SetUserRolesRequest replaces every role the user holds.
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required,min=1,dive,required"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "encoding/json"
+
+type WebAuthnRegisterFinishRequest struct {
+	SessionID  string          `json:"session_id" binding:"required,uuid"`
+	Name       string          `json:"name" binding:"max=64"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type WebAuthnLoginFinishRequest struct {
+	SessionID  string          `json:"session_id" binding:"required,uuid"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
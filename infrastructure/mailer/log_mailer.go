@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogMailer is meant for local runs: it logs every message and, when an
+// outbox directory is configured, also writes it to
+// <dir>/<recipient>/<timestamp>.eml so tests can read the links it contains.
+type LogMailer struct {
+	dir string
+}
+
+func NewLogMailer(dir string) *LogMailer {
+	return &LogMailer{dir: dir}
+}
+
+func (m *LogMailer) Send(msg notification.Message) error {
+	utils.Logger.Info("outgoing email",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	if m.dir == "" {
+		return nil
+	}
+
+	dir := filepath.Join(m.dir, filepath.Base(msg.To))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	now := time.Now()
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s", msg.To, msg.Subject, now.Format(time.RFC1123Z), msg.Body)
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.eml", now.UnixNano())), []byte(content), 0o600)
+}
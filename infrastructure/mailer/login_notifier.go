@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"time"
+)
+
+// LoginNotifier sends login alerts as email through any Mailer.
+type LoginNotifier struct {
+	mailer notification.Mailer
+}
+
+func NewLoginNotifier(mailer notification.Mailer) *LoginNotifier {
+	return &LoginNotifier{mailer: mailer}
+}
+
+func (n *LoginNotifier) NotifyNewLogin(alert notification.LoginAlert) error {
+	return n.mailer.Send(notification.Message{
+		To:      alert.To,
+		Subject: "New sign-in to your account",
+		Body: fmt.Sprintf("Your account was just signed in to from a new device or location:\n\n"+
+			"Device: %s\nIP address: %s\nTime: %s\n\n"+
+			"If this was you, there is nothing to do.\n\n"+
+			"If this wasn't you, open this link to sign out every session and choose a new password:\n%s\n",
+			alert.DeviceLabel, alert.IP, alert.At.UTC().Format(time.RFC1123), alert.DisownURL),
+	})
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileBreachedPasswordRepository serves range queries from a local copy of a
+// breached password list in the Pwned Passwords download format: one upper
+// case SHA-1 per line, optionally followed by ":<count>".
+type FileBreachedPasswordRepository struct {
+	ranges map[string][]string
+}
+
+func NewFileBreachedPasswordRepository(path string) (*FileBreachedPasswordRepository, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ranges := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		if len(hash) != 40 {
+			return nil, fmt.Errorf("%s:%d: not a SHA-1 hash", path, line)
+		}
+		hash = strings.ToUpper(hash)
+		ranges[hash[:5]] = append(ranges[hash[:5]], hash[5:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &FileBreachedPasswordRepository{ranges: ranges}, nil
+}
+
+func (r *FileBreachedPasswordRepository) Range(prefix string) ([]string, error) {
+	return r.ranges[strings.ToUpper(prefix)], nil
+}
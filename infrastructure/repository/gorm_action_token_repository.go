@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormActionTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormActionTokenRepository(db *gorm.DB) *GormActionTokenRepository {
+	return &GormActionTokenRepository{db}
+}
+
+func (r *GormActionTokenRepository) Create(token *models.ActionToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *GormActionTokenRepository) Consume(tokenHash, purpose string) (*models.ActionToken, error) {
+	var tokens []models.ActionToken
+	now := time.Now()
+	err := r.db.Model(&tokens).Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("used_at", now).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &tokens[0], nil
+}
+
+func (r *GormActionTokenRepository) FindValid(tokenHash, purpose string) (*models.ActionToken, error) {
+	var token models.ActionToken
+	err := r.db.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *GormActionTokenRepository) FindLatest(userID uuid.UUID, purpose string) (*models.ActionToken, error) {
+	var token models.ActionToken
+	err := r.db.Where("user_id = ? AND purpose = ?", userID, purpose).Order("created_at DESC").First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *GormActionTokenRepository) InvalidateForUser(userID uuid.UUID, purpose string) error {
+	return r.db.Model(&models.ActionToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type GormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAPIKeyRepository(db *gorm.DB) *GormAPIKeyRepository {
+	return &GormAPIKeyRepository{db}
+}
+
+func (r *GormAPIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *GormAPIKeyRepository) FindActiveByHash(hash string, now time.Time) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ? AND revoked_at IS NULL AND expires_at > ?", hash, now).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *GormAPIKeyRepository) ListForUser(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+func (r *GormAPIKeyRepository) Revoke(userID, id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormAPIKeyRepository) TouchLastUsed(id uuid.UUID, at time.Time, ip string) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).
+		Updates(map[string]any{"last_used_at": at, "last_used_ip": ip}).Error
+}
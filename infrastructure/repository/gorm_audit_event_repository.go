@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+)
+
+type GormAuditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAuditEventRepository(db *gorm.DB) *GormAuditEventRepository {
+	return &GormAuditEventRepository{db}
+}
+
+func (r *GormAuditEventRepository) Create(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormBotChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormBotChallengeRepository(db *gorm.DB) *GormBotChallengeRepository {
+	return &GormBotChallengeRepository{db}
+}
+
+func (r *GormBotChallengeRepository) Spend(challenge *models.SpentBotChallenge) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(challenge)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *GormBotChallengeRepository) PurgeExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.SpentBotChallenge{}).Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormKnownDeviceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormKnownDeviceRepository(db *gorm.DB) *GormKnownDeviceRepository {
+	return &GormKnownDeviceRepository{db}
+}
+
+func (r *GormKnownDeviceRepository) Touch(userID uuid.UUID, fingerprint string, at time.Time) (bool, error) {
+	result := r.db.Model(&models.KnownDevice{}).
+		Where("user_id = ? AND fingerprint = ?", userID, fingerprint).
+		Update("last_seen_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormKnownDeviceRepository) Create(device *models.KnownDevice) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(device)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormKnownDeviceRepository) HasAny(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormKnownDeviceRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.KnownDevice{}).Error
+}
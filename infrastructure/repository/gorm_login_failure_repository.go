@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormLoginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLoginFailureRepository(db *gorm.DB) *GormLoginFailureRepository {
+	return &GormLoginFailureRepository{db}
+}
+
+func (r *GormLoginFailureRepository) Find(userID uuid.UUID) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+	if err := r.db.First(&failure, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+func (r *GormLoginFailureRepository) RecordFailure(userID uuid.UUID, at time.Time) (int, error) {
+	failure := models.LoginFailure{UserID: userID, FailedAttempts: 1, LastFailedAt: at}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_attempts": gorm.Expr("login_failures.failed_attempts + 1"),
+				"last_failed_at":  at,
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "failed_attempts"}}},
+	).Create(&failure).Error
+	return failure.FailedAttempts, err
+}
+
+func (r *GormLoginFailureRepository) SetLockedUntil(userID uuid.UUID, until time.Time) error {
+	return r.db.Model(&models.LoginFailure{}).Where("user_id = ?", userID).Update("locked_until", until).Error
+}
+
+func (r *GormLoginFailureRepository) Reset(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.LoginFailure{}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+)
+
+type GormMFARepository struct {
+	db *gorm.DB
+}
+
+func NewGormMFARepository(db *gorm.DB) *GormMFARepository {
+	return &GormMFARepository{db}
+}
+
+func (r *GormMFARepository) FindTOTP(userID uuid.UUID) (*models.TOTPFactor, error) {
+	var factor models.TOTPFactor
+	err := r.db.First(&factor, "user_id = ?", userID).Error
+	return &factor, err
+}
+
+func (r *GormMFARepository) SaveTOTP(factor *models.TOTPFactor) error {
+	return r.db.Save(factor).Error
+}
+
+func (r *GormMFARepository) MarkTOTPStepUsed(userID uuid.UUID, step int64) (bool, error) {
+	res := r.db.Model(&models.TOTPFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type GormOAuthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewGormOAuthClientRepository(db *gorm.DB) *GormOAuthClientRepository {
+	return &GormOAuthClientRepository{db}
+}
+
+func (r *GormOAuthClientRepository) Create(client *models.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+func (r *GormOAuthClientRepository) FindActive(id uuid.UUID) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := r.db.Where("id = ? AND revoked_at IS NULL", id).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *GormOAuthClientRepository) List() ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	err := r.db.Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+func (r *GormOAuthClientRepository) Revoke(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.OAuthClient{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormOAuthClientRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.OAuthClient{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type GormPhoneVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPhoneVerificationRepository(db *gorm.DB) *GormPhoneVerificationRepository {
+	return &GormPhoneVerificationRepository{db}
+}
+
+func (r *GormPhoneVerificationRepository) Replace(verification *models.PhoneVerification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PhoneVerification{}).
+			Where("user_id = ? AND used_at IS NULL", verification.UserID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(verification).Error
+	})
+}
+
+func (r *GormPhoneVerificationRepository) FindLatest(userID uuid.UUID) (*models.PhoneVerification, error) {
+	var verification models.PhoneVerification
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *GormPhoneVerificationRepository) ClaimAttempt(id uuid.UUID, maxAttempts int) (bool, error) {
+	result := r.db.Model(&models.PhoneVerification{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *GormPhoneVerificationRepository) MarkUsed(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.PhoneVerification{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type GormRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRecoveryCodeRepository(db *gorm.DB) *GormRecoveryCodeRepository {
+	return &GormRecoveryCodeRepository{db}
+}
+
+func (r *GormRecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *GormRecoveryCodeRepository) FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.RecoveryCode, error) {
+	var code models.RecoveryCode
+	err := r.db.Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *GormRecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *GormRecoveryCodeRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+)
+
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoleRepository(db *gorm.DB) *GormRoleRepository {
+	return &GormRoleRepository{db}
+}
+
+func (r *GormRoleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *GormRoleRepository) FindByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Where("name IN ?", names).Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *GormRoleRepository) ListForUser(userID uuid.UUID) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("name").
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *GormRoleRepository) PermissionsForUser(userID uuid.UUID) ([]string, error) {
+	var permissions []string
+	err := r.db.Model(&models.RolePermission{}).
+		Distinct("role_permissions.permission").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("role_permissions.permission").
+		Pluck("role_permissions.permission", &permissions).Error
+	return permissions, err
+}
+
+func (r *GormRoleRepository) ReplaceForUser(userID uuid.UUID, roleIDs []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if len(roleIDs) == 0 {
+			return nil
+		}
+		assignments := make([]models.UserRole, len(roleIDs))
+		for i, roleID := range roleIDs {
+			assignments[i] = models.UserRole{UserID: userID, RoleID: roleID}
+		}
+		return tx.Create(&assignments).Error
+	})
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormTokenRevocationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTokenRevocationRepository(db *gorm.DB) *GormTokenRevocationRepository {
+	return &GormTokenRevocationRepository{db}
+}
+
+func (r *GormTokenRevocationRepository) RevokeToken(token *models.RevokedToken) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *GormTokenRevocationRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormTokenRevocationRepository) RevokeUserTokens(userID uuid.UUID, before time.Time) error {
+	revocation := models.UserTokenRevocation{UserID: userID, RevokedBefore: before}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+	}).Create(&revocation).Error
+}
+
+func (r *GormTokenRevocationRepository) UserRevokedBefore(userID uuid.UUID) (time.Time, error) {
+	var revocation models.UserTokenRevocation
+	err := r.db.First(&revocation, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return revocation.RevokedBefore, err
+}
+
+func (r *GormTokenRevocationRepository) PurgeExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/sandroJayas/user-service/models"
 	"gorm.io/gorm"
@@ -14,8 +15,35 @@ func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
 	return &GormUserRepository{db}
 }
 
-func (r *GormUserRepository) CreateUser(user *models.User) error {
-	return r.db.Create(user).Error
+// CreateUser returns gorm.ErrDuplicatedKey if an active user already has the
+// email. Nothing is stored unless every role exists.
+func (r *GormUserRepository) CreateUser(user *models.User, roleNames ...string) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		for _, name := range roleNames {
+			var role models.Role
+			if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if isUniqueViolation(err) {
+		return gorm.ErrDuplicatedKey
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err is Postgres error 23505. The driver's
+// error type exposes its code through SQLState.
+func isUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
 }
 
 func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
@@ -32,6 +60,10 @@ func (r *GormUserRepository) Save(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+func (r *GormUserRepository) UpdatePassword(id uuid.UUID, hash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
 func (r *GormUserRepository) SoftDelete(id uuid.UUID) error {
 	return r.db.Model(&models.User{}).Where("id = ?", id).Update("is_deleted", true).Error
 }
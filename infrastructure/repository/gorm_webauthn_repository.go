@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GormWebAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWebAuthnRepository(db *gorm.DB) *GormWebAuthnRepository {
+	return &GormWebAuthnRepository{db}
+}
+
+func (r *GormWebAuthnRepository) CreateCredential(credential *models.WebAuthnCredential) error {
+	return r.db.Create(credential).Error
+}
+
+func (r *GormWebAuthnRepository) FindCredentialsByUser(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *GormWebAuthnRepository) SaveCredential(credential *models.WebAuthnCredential) error {
+	return r.db.Save(credential).Error
+}
+
+func (r *GormWebAuthnRepository) CreateSession(session *models.WebAuthnSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *GormWebAuthnRepository) TakeSession(id uuid.UUID, ceremony string) (*models.WebAuthnSession, error) {
+	var sessions []models.WebAuthnSession
+	err := r.db.Clauses(clause.Returning{}).
+		Where("id = ? AND ceremony = ? AND expires_at > ?", id, ceremony, time.Now()).
+		Delete(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &sessions[0], nil
+}
+
+func (r *GormWebAuthnRepository) PurgeExpiredSessions() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{}).Error
+}
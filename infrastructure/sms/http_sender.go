@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPSender posts messages as JSON to an SMS provider's API:
+//
+//	{"from": "...", "to": "...", "body": "..."}
+//
+// authenticated with a bearer token. Any 2xx response counts as accepted.
+type HTTPSender struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+func NewHTTPSender(url, token, from string) *HTTPSender {
+	return &HTTPSender{
+		url:    url,
+		token:  token,
+		from:   from,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSender) Send(msg notification.SMS) error {
+	payload, err := json.Marshal(map[string]string{"from": s.from, "to": msg.To, "body": msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms provider returned %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
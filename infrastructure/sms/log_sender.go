@@ -0,0 +1,38 @@
+package sms
+
+import (
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogSender is the LogMailer of text messages: it logs every message and,
+// when an outbox directory is configured, also writes it to
+// <dir>/<number>/<timestamp>.txt so tests can read the codes.
+type LogSender struct {
+	dir string
+}
+
+func NewLogSender(dir string) *LogSender {
+	return &LogSender{dir: dir}
+}
+
+func (s *LogSender) Send(msg notification.SMS) error {
+	utils.Logger.Info("outgoing sms",
+		zap.String("to", msg.To),
+		zap.String("body", msg.Body),
+	)
+	if s.dir == "" {
+		return nil
+	}
+
+	dir := filepath.Join(s.dir, filepath.Base(msg.To))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.txt", time.Now().UnixNano())), []byte(msg.Body), 0o600)
+}
@@ -1,19 +1,59 @@
 package middleware
 
 import (
-	"fmt"
 	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// TokenRevocationChecker reports whether a signature-valid token has been
+// revoked server side, e.g. by a logout or account deletion, or belongs to
+// a session that has been ended.
+type TokenRevocationChecker interface {
+	IsRevoked(userID uuid.UUID, jti string, issuedAt, expiresAt time.Time) (bool, error)
+	IsSessionRevoked(sessionID uuid.UUID) (bool, error)
+}
+
+// ClientStatusChecker reports whether a machine client, and so every token
+// issued to it, is still allowed in.
+type ClientStatusChecker interface {
+	IsClientActive(clientID string) (bool, error)
+}
+
+// APIKeyAuthenticator resolves a personal API key to its owner, with the
+// owner's Permissions filled in. It returns a nil key, and no error, when the
+// key must be refused.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key, ip string) (*models.APIKey, *models.User, error)
+}
+
+// AuthMiddleware authenticates users. Tokens whose subject is a service are
+// rejected; routes meant for services use ServiceAuthMiddleware.
+//
+// It stores the effective user, whose account the request acts on, under
+// "user_id", and the person actually making the request under "actor_id";
+// they differ only for impersonation tokens, which also set "impersonated".
+// Tokens issued at login also store their "session_id" and "auth_time", see
+// RequireRecentAuth. The "permissions" granted by the user's roles are
+// checked with RequirePermission.
+// Impersonation tokens are refused unless the route opts in with
+// AllowImpersonation.
+//
+// With an APIKeyAuthenticator it also accepts "Authorization: ApiKey <key>"
+// and additionally stores "api_key_id" and the key's "scopes". Routes that
+// accept API keys should say which scope they need with RequireAPIKeyScope.
+func AuthMiddleware(revocations TokenRevocationChecker, apiKeys ...APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+		if len(apiKeys) > 0 && strings.HasPrefix(authHeader, "ApiKey ") {
+			authenticateAPIKey(c, strings.TrimPrefix(authHeader, "ApiKey "), apiKeys)
+			return
+		}
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
 			return
@@ -21,13 +61,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+		token, err := utils.ParseAccessToken(tokenStr)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error":   "Invalid token",
@@ -35,27 +69,148 @@ func AuthMiddleware() gin.HandlerFunc {
 			})
 			return
 		}
-		if !token.Valid {
+		if token.IsService() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a user token"})
+			return
+		}
+		if token.IsImpersonation() && !c.GetBool("impersonation_allowed") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available while impersonating"})
+			return
+		}
+
+		revoked, err := revocations.IsRevoked(token.UserID, token.JTI, token.IssuedAt, token.ExpiresAt)
+		if err == nil && !revoked && token.SessionID != uuid.Nil {
+			revoked, err = revocations.IsSessionRevoked(token.SessionID)
+		}
+		if err == nil && !revoked && token.IsImpersonation() {
+			// Signing the actor out everywhere also ends their
+			// impersonations.
+			revoked, err = revocations.IsRevoked(token.ActorID, token.JTI, token.IssuedAt, token.ExpiresAt)
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not verify token"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
+
+		actorID := token.UserID
+		if token.IsImpersonation() {
+			actorID = token.ActorID
+		}
+		c.Set("subject_type", "user")
+		c.Set("user_id", token.UserID)
+		c.Set("actor_id", actorID)
+		c.Set("impersonated", token.IsImpersonation())
+		c.Set("account_type", token.AccountType)
+		c.Set("permissions", token.Permissions)
+		c.Set("amr", token.AMR)
+		c.Set("email_verified", token.EmailVerified)
+		c.Set("jti", token.JTI)
+		c.Set("token_expires_at", token.ExpiresAt)
+		if token.SessionID != uuid.Nil {
+			c.Set("session_id", token.SessionID)
+		}
+		if !token.AuthTime.IsZero() {
+			c.Set("auth_time", token.AuthTime)
+		}
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, raw string, apiKeys []APIKeyAuthenticator) {
+	for _, keys := range apiKeys {
+		key, user, err := keys.AuthenticateAPIKey(raw, c.ClientIP())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not verify API key"})
+			return
+		}
+		if key == nil {
+			continue
+		}
+
+		c.Set("subject_type", "user")
+		c.Set("user_id", user.ID)
+		c.Set("actor_id", user.ID)
+		c.Set("impersonated", false)
+		c.Set("account_type", user.AccountType)
+		c.Set("permissions", user.Permissions)
+		c.Set("amr", []string{utils.AMRAPIKey})
+		c.Set("email_verified", user.EmailVerifiedAt != nil)
+		c.Set("api_key_id", key.ID.String())
+		c.Set("scopes", key.ScopeList())
+		c.Set("token_expires_at", key.ExpiresAt)
+		c.Next()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+}
+
+// ServiceAuthMiddleware authenticates machine clients by a token from the
+// client-credentials grant and stores "client_id" and "scopes". Pair it with
+// RequireScope.
+func ServiceAuthMiddleware(clients ClientStatusChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+			return
+		}
+
+		token, err := utils.ParseAccessToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token",
+				"error":   "Invalid token",
+				"details": err.Error(),
 			})
 			return
 		}
+		if !token.IsService() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a service token"})
+			return
+		}
+
+		active, err := clients.IsClientActive(token.ClientID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not verify token"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			return
+		}
 
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user_id claim is missing or invalid"})
+		c.Set("subject_type", "service")
+		c.Set("client_id", token.ClientID)
+		c.Set("scopes", token.Scopes)
+		c.Set("jti", token.JTI)
+		c.Set("token_expires_at", token.ExpiresAt)
+		c.Next()
+	}
+}
+
+// PurposeTokenMiddleware authenticates a request with a restricted token from
+// utils.GeneratePurposeToken instead of an access token. It only proves the
+// user finished an earlier step of the flow the token was issued for.
+func PurposeTokenMiddleware(use string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
 			return
 		}
 
-		userID, err := uuid.Parse(userIDStr)
+		claims, err := utils.ParsePurposeToken(strings.TrimPrefix(authHeader, "Bearer "), use)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID format"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			return
 		}
 
-		c.Set("user_id", userID)
-		c.Set("account_type", claims["account_type"])
+		c.Set("user_id", claims.UserID)
+		c.Set("jti", claims.JTI)
+		c.Set("token_expires_at", claims.ExpiresAt)
 		c.Next()
 	}
 }
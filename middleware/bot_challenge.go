@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+)
+
+// BotChallenger decides when a client must prove it is not a bot and checks
+// the proof.
+type BotChallenger interface {
+	// Assess counts the attempt and reports whether it needs a proof.
+	Assess(ip, emailDomain, userAgent string) bool
+	NewChallenge() (token string, difficulty int, expiresAt time.Time, err error)
+	CaptchaEnabled() bool
+	VerifySolution(ctx context.Context, challenge, solution, captchaResponse, ip string) (bool, error)
+}
+
+// Headers carrying a proof. A proof-of-work answer needs both the challenge
+// token and the solution.
+const (
+	BotChallengeHeader = "X-Bot-Challenge"
+	BotSolutionHeader  = "X-Bot-Solution"
+	CaptchaHeader      = "X-Captcha-Response"
+)
+
+// BotChallenge protects unauthenticated routes that take an "email" in
+// their JSON body. While the caller's IP address, user agent, or email
+// domain is over its limit, requests without a valid proof get 428 with a
+// fresh proof-of-work challenge, and a flag saying whether a CAPTCHA
+// response is accepted instead.
+func BotChallenge(challenger BotChallenger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if !challenger.Assess(ip, requestEmailDomain(c), c.Request.UserAgent()) {
+			c.Next()
+			return
+		}
+
+		ok, err := challenger.VerifySolution(c.Request.Context(),
+			c.GetHeader(BotChallengeHeader), c.GetHeader(BotSolutionHeader), c.GetHeader(CaptchaHeader), ip)
+		if err != nil {
+			// A CAPTCHA provider outage must not lock everyone out; the
+			// client still gets a proof-of-work challenge below.
+			utils.Logger.Error("bot challenge verification failed", zap.Error(err))
+		}
+		if ok {
+			c.Next()
+			return
+		}
+
+		token, difficulty, expiresAt, err := challenger.NewChallenge()
+		if err != nil {
+			utils.Logger.Error("bot challenge generation failed", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Could not generate challenge"})
+			return
+		}
+		utils.Logger.Info("bot challenge issued",
+			zap.String("event", "bot_challenge_issued"),
+			zap.String("ip", ip),
+			zap.String("path", c.FullPath()),
+		)
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+			"error": "challenge required",
+			"challenge": gin.H{
+				"type":       "proof_of_work",
+				"algorithm":  "sha256",
+				"token":      token,
+				"difficulty": difficulty,
+				"expires_at": expiresAt,
+			},
+			"captcha": challenger.CaptchaEnabled(),
+		})
+	}
+}
+
+// requestEmailDomain peeks at the "email" field of a JSON body and puts the
+// body back for the handler.
+func requestEmailDomain(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	at := strings.LastIndex(payload.Email, "@")
+	if at < 0 {
+		return ""
+	}
+	return payload.Email[at+1:]
+}
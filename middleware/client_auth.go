@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ClientAuthenticator checks the credentials of a calling service rather
+// than a user.
+type ClientAuthenticator interface {
+	AuthenticateClient(id, secret string) (bool, error)
+}
+
+// ClientAuthMiddleware requires HTTP Basic client credentials, as RFC 6749
+// section 2.3.1 describes, that any of the authenticators accepts. The
+// client ID is stored under "client_id".
+func ClientAuthMiddleware(authenticators ...ClientAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, secret, ok := c.Request.BasicAuth()
+		authenticated := false
+		for _, clients := range authenticators {
+			if !ok || authenticated {
+				break
+			}
+			var err error
+			authenticated, err = clients.AuthenticateClient(id, secret)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+				return
+			}
+		}
+		if !authenticated {
+			c.Header("WWW-Authenticate", `Basic realm="user-service"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+			return
+		}
+
+		c.Set("client_id", id)
+		c.Next()
+	}
+}
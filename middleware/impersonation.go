@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImpersonationAuditor records requests that employees make while
+// impersonating a user.
+type ImpersonationAuditor interface {
+	RecordImpersonatedRequest(actorID, userID uuid.UUID, jti, method, path, ip string, status int)
+}
+
+// AllowImpersonation lets impersonation tokens through the AuthMiddleware
+// that follows it on the route, and records each impersonated request with
+// its response status once the handler has run. It must come before
+// AuthMiddleware.
+func AllowImpersonation(auditor ImpersonationAuditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("impersonation_allowed", true)
+		c.Next()
+
+		if !c.GetBool("impersonated") {
+			return
+		}
+		auditor.RecordImpersonatedRequest(
+			c.MustGet("actor_id").(uuid.UUID),
+			c.MustGet("user_id").(uuid.UUID),
+			c.GetString("jti"),
+			c.Request.Method,
+			c.FullPath(),
+			c.ClientIP(),
+			c.Writer.Status(),
+		)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRecentAuth rejects requests whose token was not obtained by
+// authenticating within maxAge, so a stolen or long-lived token is not
+// enough for sensitive operations. Clients get a fresh token from
+// POST /users/reauth. Place it after AuthMiddleware; API keys never pass.
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !AuthenticatedWithin(c, maxAge) {
+			AbortReauthRequired(c, maxAge)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthenticatedWithin reports whether the caller's token carries an
+// auth_time no older than maxAge, for handlers where only some requests are
+// sensitive.
+func AuthenticatedWithin(c *gin.Context, maxAge time.Duration) bool {
+	authTime := c.GetTime("auth_time")
+	return !authTime.IsZero() && time.Since(authTime) <= maxAge
+}
+
+// AbortReauthRequired answers with the RFC 9470 step-up challenge.
+func AbortReauthRequired(c *gin.Context, maxAge time.Duration) {
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="recent authentication required", max_age=%d`, int(maxAge.Seconds())))
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":           "recent authentication required",
+		"reauth_required": true,
+	})
+}
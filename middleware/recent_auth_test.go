@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var authenticatedAt time.Time
+	r.POST("/sensitive", func(c *gin.Context) {
+		if !authenticatedAt.IsZero() {
+			c.Set("auth_time", authenticatedAt)
+		}
+	}, RequireRecentAuth(5*time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	call := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/sensitive", nil))
+		return w
+	}
+
+	w := call()
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+
+	authenticatedAt = time.Now().Add(-6 * time.Minute)
+	w = call()
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "max_age=300")
+
+	authenticatedAt = time.Now().Add(-time.Minute)
+	assert.Equal(t, http.StatusNoContent, call().Code)
+}
@@ -2,13 +2,18 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/config"
+	"github.com/sandroJayas/user-service/utils"
 	"net/http"
+	"slices"
 )
 
-func RequireEmployeeRole() gin.HandlerFunc {
+// RequirePermission rejects callers whose roles do not grant permission.
+// Permissions come from the access token, or from the user's roles for API
+// keys.
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userType, exists := c.Get("account_type")
-		if !exists || userType != "employee" {
+		if !slices.Contains(c.GetStringSlice("permissions"), permission) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": "insufficient permissions",
 			})
@@ -17,3 +22,71 @@ func RequireEmployeeRole() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireVerifiedEmail rejects tokens issued before the user confirmed their
+// email address. Users must sign in again after verifying to pass.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("email_verified") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "email address not verified",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireMFA rejects tokens that were obtained without a second factor when
+// the caller's account type is listed in MFA_REQUIRED_ACCOUNT_TYPES. A passkey
+// login counts as multi-factor since it requires user verification.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accountType := c.GetString("account_type")
+		if !slices.Contains(config.AppConfig.MFARequiredAccountTypes, accountType) {
+			c.Next()
+			return
+		}
+		// Creating an API key already required MFA, so the key stands in
+		// for the second factor.
+		if c.GetString("api_key_id") != "" {
+			c.Next()
+			return
+		}
+		amr := c.GetStringSlice("amr")
+		if !slices.Contains(amr, utils.AMROTP) && !slices.Contains(amr, utils.AMRHardwareKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "multi-factor authentication required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects service tokens that were not granted scope.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(c.GetStringSlice("scopes"), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "insufficient scope",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAPIKeyScope rejects API-key requests whose key was not granted
+// scope. Requests authenticated with a login token are not affected.
+func RequireAPIKeyScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("api_key_id") != "" && !slices.Contains(c.GetStringSlice("scopes"), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "insufficient scope",
+			})
+			return
+		}
+		c.Next()
+	}
+}
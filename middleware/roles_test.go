@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(permissions []string) int {
+		r := gin.New()
+		r.GET("/", func(c *gin.Context) {
+			if permissions != nil {
+				c.Set("permissions", permissions)
+			}
+		}, RequirePermission("users:read"), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve([]string{"sessions:read", "users:read"}))
+	assert.Equal(t, http.StatusForbidden, serve([]string{"sessions:read"}))
+	assert.Equal(t, http.StatusForbidden, serve(nil))
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS action_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    purpose TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uniq_action_tokens_token_hash ON action_tokens(token_hash);
+CREATE INDEX idx_action_tokens_user_id ON action_tokens(user_id);
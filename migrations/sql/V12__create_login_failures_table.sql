@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
@@ -0,0 +1 @@
+ALTER TABLE action_tokens ADD COLUMN email TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS known_devices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    fingerprint TEXT NOT NULL,
+    device_label TEXT NOT NULL DEFAULT '',
+    ip_prefix TEXT NOT NULL DEFAULT '',
+    last_seen_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX uniq_known_devices_user_fingerprint ON known_devices(user_id, fingerprint);
@@ -0,0 +1,5 @@
+ALTER TABLE refresh_tokens ADD COLUMN auth_time TIMESTAMP;
+
+UPDATE refresh_tokens SET auth_time = COALESCE(created_at, CURRENT_TIMESTAMP);
+
+ALTER TABLE refresh_tokens ALTER COLUMN auth_time SET NOT NULL;
@@ -0,0 +1,62 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    account_type TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id),
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'Look up other users'),
+    ('users:impersonate', 'Act as a customer'),
+    ('sessions:read', 'List a customer''s sessions'),
+    ('sessions:revoke', 'End a customer''s sessions'),
+    ('lockouts:read', 'See failed login lockouts'),
+    ('clients:read', 'List OAuth clients'),
+    ('clients:write', 'Register and revoke OAuth clients'),
+    ('roles:read', 'List roles and their permissions'),
+    ('roles:assign', 'Change the roles of other users'),
+    ('employee:commands', 'Run employee-only commands');
+
+INSERT INTO roles (name, description, account_type) VALUES
+    ('customer', 'Customer account', 'customer'),
+    ('employee', 'Every employee permission, held by all employees before roles existed', 'employee'),
+    ('admin', 'Every employee permission', 'employee'),
+    ('support_agent', 'Help customers with their accounts', 'employee'),
+    ('warehouse_staff', 'Look up customers to fulfil their orders', 'employee');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permissions.name FROM roles CROSS JOIN permissions
+WHERE roles.name IN ('employee', 'admin');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permission FROM roles CROSS JOIN (VALUES
+    ('users:read'), ('users:impersonate'), ('sessions:read'), ('sessions:revoke'), ('lockouts:read')
+) AS granted(permission)
+WHERE roles.name = 'support_agent';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, 'users:read' FROM roles WHERE roles.name = 'warehouse_staff';
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT users.id, roles.id FROM users JOIN roles ON roles.name = users.account_type;
@@ -0,0 +1,3 @@
+-- Failed logins for emails without an account are counted under a key
+-- derived from the email, which is not a user ID.
+ALTER TABLE login_failures DROP CONSTRAINT login_failures_user_id_fkey;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS spent_bot_challenges (
+    nonce TEXT PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_spent_bot_challenges_expires_at ON spent_bot_challenges(expires_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS totp_factors (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    secret_encrypted TEXT NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN amr TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ActionToken is a single-use token sent to a user out of band, e.g. in a
+// password reset email. Only the SHA-256 hash of the token is stored.
+type ActionToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	Purpose   string    `gorm:"not null"`
+	TokenHash string    `gorm:"not null"`
+	// Email is the address the token is about, for purposes that need one
+	// such as an email change.
+	Email     string    `gorm:"not null;default:''"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (a *ActionToken) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// APIKey is a long-lived credential a user creates for scripts and
+// integrations. Only the SHA-256 hash of the key is stored; Prefix keeps
+// enough of it for the owner to tell keys apart.
+type APIKey struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null"`
+	Name    string    `gorm:"not null"`
+	Prefix  string    `gorm:"not null"`
+	KeyHash string    `gorm:"not null"`
+	// Scopes the key grants, comma separated.
+	Scopes     string    `gorm:"not null;default:''"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	LastUsedAt *time.Time
+	LastUsedIP *string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	return
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
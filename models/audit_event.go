@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Audit event actions.
+const (
+	AuditImpersonationStarted = "impersonation_started"
+	AuditImpersonatedRequest  = "impersonated_request"
+	AuditSessionRevoked       = "session_revoked"
+	AuditRolesChanged         = "roles_changed"
+)
+
+// AuditEvent records something an employee did to or as a user. Rows are
+// only ever inserted.
+type AuditEvent struct {
+	ID      uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Action  string    `gorm:"not null"`
+	ActorID uuid.UUID `gorm:"type:uuid;not null"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null"`
+	// TokenID is the jti of the impersonation token a request was made
+	// with, or the ID of the session that was revoked.
+	TokenID string `gorm:"not null;default:''"`
+	// Reason is what the employee gave for an impersonation, or the comma
+	// separated roles a user was given.
+	Reason    string `gorm:"not null;default:''"`
+	Method    string `gorm:"not null;default:''"`
+	Path      string `gorm:"not null;default:''"`
+	Status    int    `gorm:"not null;default:0"`
+	IP        string `gorm:"not null;default:''"`
+	CreatedAt time.Time
+}
+
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	e.ID = uuid.New()
+	return
+}
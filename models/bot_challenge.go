@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SpentBotChallenge remembers a solved bot challenge by its nonce until the
+// challenge expires, so a solution works only once across all instances.
+type SpentBotChallenge struct {
+	Nonce     string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
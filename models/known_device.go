@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// KnownDevice is somewhere a user has logged in from before. Fingerprint is
+// the hash of the device label and the network the login came from, so a
+// new browser or a new network counts as a new device.
+type KnownDevice struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	Fingerprint string    `gorm:"not null"`
+	DeviceLabel string    `gorm:"not null;default:''"`
+	IPPrefix    string    `gorm:"column:ip_prefix;not null;default:''"`
+	LastSeenAt  time.Time `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+func (d *KnownDevice) BeforeCreate(tx *gorm.DB) (err error) {
+	d.ID = uuid.New()
+	return
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// LoginFailure counts consecutive failed password logins for a user. While
+// LockedUntil is in the future every login attempt is refused. Failures for
+// emails without an account are counted too, under a UserID derived from
+// the email that matches no user.
+type LoginFailure struct {
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;primaryKey"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"not null;default:0"`
+	LastFailedAt   time.Time  `json:"last_failed_at" gorm:"not null"`
+	LockedUntil    *time.Time `json:"locked_until"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TOTPFactor is a user's authenticator app enrolment. The secret is stored
+// AES-GCM encrypted and the factor only counts once ConfirmedAt is set.
+type TOTPFactor struct {
+	UserID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	SecretEncrypted string    `gorm:"not null"`
+	ConfirmedAt     *time.Time
+	// LastUsedStep is the most recent accepted time step, so a code cannot
+	// be replayed within its validity window.
+	LastUsedStep int64 `gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (TOTPFactor) TableName() string {
+	return "totp_factors"
+}
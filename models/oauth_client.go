@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// OAuthClient is a machine client, such as another Sort service, that
+// authenticates with the client-credentials grant. Its ID doubles as the
+// OAuth client_id. Only the SHA-256 hash of the secret is stored.
+type OAuthClient struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name       string    `gorm:"not null"`
+	SecretHash string    `gorm:"not null"`
+	// Scopes the client may request, comma separated.
+	Scopes     string    `gorm:"not null;default:''"`
+	CreatedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (c *OAuthClient) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+func (c *OAuthClient) ScopeList() []string {
+	if c.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(c.Scopes, ",")
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PhoneVerification is a one-time code texted to a user's phone number.
+// Codes are short, so each one only allows a few guesses. Only the SHA-256
+// hash of the code is stored.
+type PhoneVerification struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	PhoneNumber string    `gorm:"not null"`
+	CodeHash    string    `gorm:"not null"`
+	Attempts    int       `gorm:"not null;default:0"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	UsedAt      *time.Time
+	CreatedAt   time.Time
+}
+
+func (v *PhoneVerification) BeforeCreate(tx *gorm.DB) (err error) {
+	v.ID = uuid.New()
+	return
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RefreshToken is a server-side record of an opaque refresh token. Only the
+// SHA-256 hash of the token is stored. Every refresh rotates the token and
+// the rotated tokens share a FamilyID, so reuse of an already rotated token
+// can revoke the whole chain.
+type RefreshToken struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null"`
+	FamilyID   uuid.UUID `gorm:"type:uuid;not null"`
+	TokenHash  string    `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID `gorm:"type:uuid"`
+	// AMR carries the login's authentication methods, comma separated, into
+	// every access token minted from this family.
+	AMR string `gorm:"column:amr;not null;default:''"`
+	// AuthTime is when the user logged in, carried into the auth_time claim
+	// of every access token minted from this family.
+	AuthTime  time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Permissions checked with middleware.RequirePermission. Other services see
+// them in the permissions claim of access tokens and in introspection
+// responses.
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersImpersonate = "users:impersonate"
+	PermissionSessionsRead     = "sessions:read"
+	PermissionSessionsRevoke   = "sessions:revoke"
+	PermissionLockoutsRead     = "lockouts:read"
+	PermissionClientsRead      = "clients:read"
+	PermissionClientsWrite     = "clients:write"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesAssign      = "roles:assign"
+	PermissionEmployeeCommands = "employee:commands"
+)
+
+// Seeded roles. Customers get RoleCustomer when they register. Employees
+// start without roles and get the ones chosen by whoever creates them;
+// RoleEmployee grants every permission and is only held by accounts that
+// predate roles.
+const (
+	RoleCustomer       = "customer"
+	RoleEmployee       = "employee"
+	RoleAdmin          = "admin"
+	RoleSupportAgent   = "support_agent"
+	RoleWarehouseStaff = "warehouse_staff"
+)
+
+// Role is a named set of permissions. Roles are seeded by migrations.
+// AccountType is the kind of account the role may be assigned to, so staff
+// permissions never end up on a customer.
+type Role struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name        string    `gorm:"not null"`
+	Description string    `gorm:"not null;default:''"`
+	AccountType string    `gorm:"not null"`
+	Permissions []RolePermission
+	CreatedAt   time.Time
+}
+
+// PermissionNames lists the permissions the role grants.
+func (r *Role) PermissionNames() []string {
+	names := make([]string, len(r.Permissions))
+	for i, permission := range r.Permissions {
+		names[i] = permission.Permission
+	}
+	return names
+}
+
+type RolePermission struct {
+	RoleID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Permission string    `gorm:"primaryKey"`
+}
+
+// UserRole assigns a role to a user.
+type UserRole struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RoleID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+}
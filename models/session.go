@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Session is one login on one device. Its ID is the FamilyID of the
+// session's refresh tokens and the sid claim of its access tokens, so
+// revoking it ends both.
+type Session struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null"`
+	UserAgent   string    `gorm:"not null;default:''"`
+	IP          string    `gorm:"not null;default:''"`
+	DeviceLabel string    `gorm:"not null;default:''"`
+	// AMR is how the login was authenticated, comma separated.
+	AMR        string    `gorm:"column:amr;not null;default:''"`
+	LastSeenAt time.Time `gorm:"not null"`
+	// ExpiresAt follows the newest refresh token of the session.
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RevokedToken blacklists a single access token by its jti until it would
+// have expired anyway.
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// UserTokenRevocation invalidates every access token of a user that was
+// issued before RevokedBefore.
+type UserTokenRevocation struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RevokedBefore time.Time `gorm:"not null"`
+	UpdatedAt     time.Time
+}
@@ -17,6 +17,11 @@ type User struct {
 	Password    string    `json:"password"`
 	AccountType string    `json:"account_type" gorm:"not null;default:'customer'"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// PasswordResetRequired refuses every kind of login until the password
+	// is reset, set when the user reports a login that wasn't them.
+	PasswordResetRequired bool `json:"password_reset_required" gorm:"not null;default:false"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -29,9 +34,15 @@ type User struct {
 	PostalCode   string `json:"postal_code" gorm:"not null"`
 	Country      string `json:"country" gorm:"not null"`
 	PhoneNumber  string `json:"phone_number" gorm:"not null"`
+	// PhoneVerifiedAt is cleared whenever PhoneNumber changes.
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
 
 	PaymentMethodID string `json:"payment_method_id"`
 	IsDeleted       bool   `json:"is_deleted" gorm:"default:false"`
+
+	// Permissions are granted by the user's roles. They are not stored on
+	// the user and are only filled in when authenticating an API key.
+	Permissions []string `json:"-" gorm:"-"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
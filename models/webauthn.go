@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+// WebAuthnCredential is a passkey or security key registered to a user.
+type WebAuthnCredential struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID          uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	CredentialID    []byte    `json:"-" gorm:"not null"`
+	PublicKey       []byte    `json:"-" gorm:"not null"`
+	AttestationType string    `json:"-"`
+	// Transports is the comma separated list reported at registration.
+	Transports     string     `json:"-"`
+	AAGUID         []byte     `json:"-" gorm:"column:aaguid"`
+	SignCount      int64      `json:"-" gorm:"not null;default:0"`
+	UserVerified   bool       `json:"-"`
+	BackupEligible bool       `json:"-"`
+	BackupState    bool       `json:"-"`
+	Name           string     `json:"name"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+func (c *WebAuthnCredential) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// WebAuthnSession holds the server side state of a registration or login
+// ceremony between its begin and finish calls. It is deleted when finished.
+type WebAuthnSession struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    *uuid.UUID `gorm:"type:uuid"`
+	Ceremony  string     `gorm:"not null"`
+	Data      []byte     `gorm:"not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (WebAuthnSession) TableName() string {
+	return "webauthn_sessions"
+}
+
+func (s *WebAuthnSession) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
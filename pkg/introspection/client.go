@@ -0,0 +1,167 @@
+// Package introspection lets other Sort services authenticate requests with
+// access tokens from the user service. Instead of verifying tokens and
+// tracking revocations themselves, services ask the user service's RFC 7662
+// endpoint and cache the answer briefly.
+package introspection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+type Config struct {
+	// Endpoint is the full introspection URL, e.g.
+	// http://user-service:8080/oauth/introspect.
+	Endpoint     string
+	ClientID     string
+	ClientSecret string
+	// CacheTTL is how long an answer is reused, and so how long a revoked
+	// token may keep working. Defaults to 30 seconds; negative disables the
+	// cache.
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+	// AllowImpersonation lets Middleware accept tokens that support staff
+	// obtained to act as a customer. Leave it off for endpoints that change
+	// anything.
+	AllowImpersonation bool
+}
+
+// Result is the introspection response. Only Active and Revoked are set for
+// tokens that are not active. Service tokens have ClientID and Scope set
+// instead of the user fields.
+type Result struct {
+	Active   bool   `json:"active"`
+	Revoked  bool   `json:"revoked"`
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	// Act identifies the employee behind an impersonation token.
+	Act           *Actor   `json:"act"`
+	AccountType   string   `json:"account_type"`
+	AMR           []string `json:"amr"`
+	Permissions   []string `json:"permissions"`
+	EmailVerified bool     `json:"email_verified"`
+	JTI           string   `json:"jti"`
+	IssuedAt      int64    `json:"iat"`
+	ExpiresAt     int64    `json:"exp"`
+}
+
+// Actor is the RFC 8693 act claim.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// IsService reports whether the token was issued to a machine client.
+func (r *Result) IsService() bool {
+	return r.ClientID != ""
+}
+
+// Scopes splits the space-separated Scope.
+func (r *Result) Scopes() []string {
+	return strings.Fields(r.Scope)
+}
+
+type cacheEntry struct {
+	result    *Result
+	expiresAt time.Time
+}
+
+type Client struct {
+	cfg       Config
+	mu        sync.Mutex
+	cache     map[string]cacheEntry
+	lastSweep time.Time
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Client{cfg: cfg, cache: make(map[string]cacheEntry), lastSweep: time.Now()}
+}
+
+// Introspect returns what the user service knows about token, from the
+// cache when possible. An error means the user service could not be asked,
+// not that the token is invalid.
+func (c *Client) Introspect(ctx context.Context, token string) (*Result, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if result, ok := c.cached(key); ok {
+		return result, nil
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection: unexpected status %d", resp.StatusCode)
+	}
+
+	var result Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("introspection: decoding response: %w", err)
+	}
+	c.store(key, &result)
+	return &result, nil
+}
+
+func (c *Client) cached(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// store keeps an answer for CacheTTL, or until the token expires if that is
+// sooner. Inactive tokens never become active again, but they are still
+// only kept for CacheTTL to bound memory.
+func (c *Client) store(key string, result *Result) {
+	if c.cfg.CacheTTL < 0 {
+		return
+	}
+	now := time.Now()
+	expiresAt := now.Add(c.cfg.CacheTTL)
+	if result.Active && result.ExpiresAt > 0 {
+		if exp := time.Unix(result.ExpiresAt, 0); exp.Before(expiresAt) {
+			expiresAt = exp
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) > c.cfg.CacheTTL {
+		for k, entry := range c.cache {
+			if now.After(entry.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.cache[key] = cacheEntry{result: result, expiresAt: expiresAt}
+}
@@ -0,0 +1,124 @@
+package introspection
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Middleware authenticates requests by their bearer token. It sets the same
+// context keys as the user service's own AuthMiddleware: "user_id" as a
+// uuid.UUID, "account_type", "amr", "permissions", "email_verified", "jti"
+// and "token_expires_at", plus "actor_id", the person actually making the
+// request, which differs from "user_id" only for impersonation tokens.
+// Impersonation tokens are rejected unless Config.AllowImpersonation is set.
+// Service tokens are rejected; use ServiceMiddleware for endpoints that
+// machine clients call.
+func (c *Client) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := c.authenticate(ctx)
+		if !ok {
+			return
+		}
+		if result.IsService() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Service tokens cannot access this endpoint"})
+			return
+		}
+		userID, err := uuid.Parse(result.Subject)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		actorID := userID
+		if result.Act != nil {
+			if !c.cfg.AllowImpersonation {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available while impersonating"})
+				return
+			}
+			if actorID, err = uuid.Parse(result.Act.Subject); err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				return
+			}
+		}
+
+		ctx.Set("user_id", userID)
+		ctx.Set("actor_id", actorID)
+		ctx.Set("account_type", result.AccountType)
+		ctx.Set("amr", result.AMR)
+		ctx.Set("permissions", result.Permissions)
+		ctx.Set("email_verified", result.EmailVerified)
+		ctx.Set("jti", result.JTI)
+		ctx.Set("token_expires_at", time.Unix(result.ExpiresAt, 0))
+		ctx.Next()
+	}
+}
+
+// RequirePermission rejects requests whose token was not granted
+// permission by the user's roles. Use it after Middleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !slices.Contains(ctx.GetStringSlice("permissions"), permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ServiceMiddleware authenticates machine clients holding a token from the
+// client-credentials grant that carries every one of scopes. It sets
+// "client_id", "scopes", "jti" and "token_expires_at".
+func (c *Client) ServiceMiddleware(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, ok := c.authenticate(ctx)
+		if !ok {
+			return
+		}
+		if !result.IsService() {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a service token"})
+			return
+		}
+		granted := result.Scopes()
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope"})
+				return
+			}
+		}
+
+		ctx.Set("client_id", result.ClientID)
+		ctx.Set("scopes", granted)
+		ctx.Set("jti", result.JTI)
+		ctx.Set("token_expires_at", time.Unix(result.ExpiresAt, 0))
+		ctx.Next()
+	}
+}
+
+// authenticate introspects the bearer token and aborts the request unless
+// it is active.
+func (c *Client) authenticate(ctx *gin.Context) (*Result, bool) {
+	authHeader := ctx.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+		return nil, false
+	}
+
+	result, err := c.Introspect(ctx.Request.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Could not verify token"})
+		return nil, false
+	}
+	if result.Revoked {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+		return nil, false
+	}
+	if !result.Active {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+	return result, true
+}
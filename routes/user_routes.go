@@ -2,15 +2,31 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/config"
 	"github.com/sandroJayas/user-service/controllers"
 	"github.com/sandroJayas/user-service/middleware"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/usecase"
+	"github.com/sandroJayas/user-service/utils"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 	"net/http"
 )
 
-func RegisterUserRoutes(r *gin.Engine, controller *controllers.UserController, db *gorm.DB) {
+func RegisterUserRoutes(r *gin.Engine, controller *controllers.UserController, mfaController *controllers.MFAController, recoveryController *controllers.RecoveryController, webauthnController *controllers.WebAuthnController, passwordController *controllers.PasswordController, verificationController *controllers.EmailVerificationController, emailChangeController *controllers.EmailChangeController, oauthController *controllers.OAuthController, apiKeyController *controllers.APIKeyController, impersonationController *controllers.ImpersonationController, phoneController *controllers.PhoneVerificationController, sessionController *controllers.SessionController, roleController *controllers.RoleController, revocations middleware.TokenRevocationChecker, apiKeys middleware.APIKeyAuthenticator, serviceClients middleware.ClientAuthenticator, oauthClients *usecase.OAuthClientService, impersonationAudit middleware.ImpersonationAuditor, bots middleware.BotChallenger, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(revocations)
+	// keyAuth also accepts personal API keys. Only use it on routes that
+	// check a scope with RequireAPIKeyScope.
+	keyAuth := middleware.AuthMiddleware(revocations, apiKeys)
+	serviceAuth := middleware.ServiceAuthMiddleware(oauthClients)
+	// Put allowImpersonation before auth on read-only routes that support
+	// staff may use while acting as a customer.
+	allowImpersonation := middleware.AllowImpersonation(impersonationAudit)
+	// recentAuth guards routes that change credentials or could lock the
+	// owner out; clients step up with POST /users/reauth.
+	recentAuth := middleware.RequireRecentAuth(config.AppConfig.RecentAuthMaxAge)
+	botChallenge := middleware.BotChallenge(bots)
 
 	r.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -27,16 +43,72 @@ func RegisterUserRoutes(r *gin.Engine, controller *controllers.UserController, d
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	r.GET("/.well-known/jwks.json", controller.JWKS)
+
+	oauth := r.Group("/oauth")
+	{
+		oauth.POST("/token", middleware.RateLimitMiddleware(), oauthController.Token)
+		oauth.POST("/introspect", middleware.ClientAuthMiddleware(serviceClients, oauthClients), oauthController.Introspect)
+		oauth.POST("/clients", auth, middleware.RequirePermission(models.PermissionClientsWrite), middleware.RequireMFA(), oauthController.CreateClient)
+		oauth.GET("/clients", keyAuth, middleware.RequireAPIKeyScope(usecase.ScopeClientsRead), middleware.RequirePermission(models.PermissionClientsRead), middleware.RequireMFA(), oauthController.ListClients)
+		oauth.DELETE("/clients/:id", auth, middleware.RequirePermission(models.PermissionClientsWrite), middleware.RequireMFA(), oauthController.RevokeClient)
+	}
+
+	r.GET("/roles", auth, middleware.RequirePermission(models.PermissionRolesRead), middleware.RequireMFA(), roleController.ListRoles)
+
+	internal := r.Group("/internal")
+	{
+		internal.GET("/users/:id", serviceAuth, middleware.RequireScope(usecase.ScopeUsersRead), controller.GetUserForService)
+	}
+
 	users := r.Group("/users")
 	{
-		users.POST("/register", middleware.RateLimitMiddleware(), controller.Register)
-		users.POST("/login", middleware.RateLimitMiddleware(), controller.Login)
-		users.GET("/me", middleware.AuthMiddleware(), controller.Me)
-		users.PUT("/profile", middleware.AuthMiddleware(), controller.UpdateProfile)
-		users.DELETE("/delete", middleware.AuthMiddleware(), controller.DeleteUser)
-
-		users.POST("/create-employee", controller.CreateEmployee)
-		users.POST("/special", middleware.AuthMiddleware(), middleware.RequireEmployeeRole(), controller.SpecialEmployeeEndpoint)
+		users.POST("/register", middleware.RateLimitMiddleware(), botChallenge, controller.Register)
+		users.POST("/login", middleware.RateLimitMiddleware(), botChallenge, controller.Login)
+		users.POST("/login/mfa", middleware.RateLimitMiddleware(), controller.LoginMFA)
+		users.POST("/login/disown", middleware.RateLimitMiddleware(), passwordController.DisownLogin)
+		users.POST("/login/magic-link", middleware.RateLimitMiddleware(), controller.SendMagicLink)
+		users.POST("/login/magic-link/consume", middleware.RateLimitMiddleware(), controller.ConsumeMagicLink)
+		users.POST("/recover", middleware.RateLimitMiddleware(), recoveryController.Recover)
+		users.POST("/recover/reset", middleware.RateLimitMiddleware(), middleware.PurposeTokenMiddleware(utils.TokenUseRecovery), recoveryController.ResetAfterRecovery)
+		users.POST("/token/refresh", middleware.RateLimitMiddleware(), controller.RefreshToken)
+		users.POST("/password/forgot", middleware.RateLimitMiddleware(), passwordController.ForgotPassword)
+		users.POST("/password/reset", middleware.RateLimitMiddleware(), passwordController.ResetPassword)
+		users.GET("/verify-email", middleware.RateLimitMiddleware(), verificationController.VerifyEmail)
+		users.POST("/verify-email/resend", middleware.RateLimitMiddleware(), verificationController.ResendVerification)
+		users.POST("/email/confirm", middleware.RateLimitMiddleware(), emailChangeController.ConfirmEmailChange)
+		users.POST("/email/revert", middleware.RateLimitMiddleware(), emailChangeController.RevertEmailChange)
+		users.POST("/webauthn/login/begin", middleware.RateLimitMiddleware(), webauthnController.BeginLogin)
+		users.POST("/webauthn/login/finish", middleware.RateLimitMiddleware(), webauthnController.FinishLogin)
+		users.POST("/webauthn/register/begin", auth, recentAuth, middleware.RequireVerifiedEmail(), webauthnController.BeginRegistration)
+		users.POST("/webauthn/register/finish", auth, middleware.RequireVerifiedEmail(), webauthnController.FinishRegistration)
+		users.POST("/reauth", middleware.RateLimitMiddleware(), auth, controller.Reauth)
+		users.POST("/logout", allowImpersonation, auth, controller.Logout)
+		users.POST("/logout-all", auth, controller.LogoutAll)
+		users.GET("/me", allowImpersonation, keyAuth, middleware.RequireAPIKeyScope(usecase.ScopeProfileRead), controller.Me)
+		users.POST("/me/mfa/totp", auth, recentAuth, mfaController.EnrollTOTP)
+		users.POST("/me/mfa/totp/confirm", auth, mfaController.ConfirmTOTP)
+		users.POST("/me/recovery-codes", auth, recentAuth, recoveryController.GenerateRecoveryCodes)
+		users.PUT("/me/password", auth, controller.ChangePassword)
+		users.POST("/me/email", auth, recentAuth, emailChangeController.RequestEmailChange)
+		users.POST("/me/phone/code", middleware.RateLimitMiddleware(), auth, phoneController.SendPhoneCode)
+		users.POST("/me/phone/verify", middleware.RateLimitMiddleware(), auth, phoneController.VerifyPhone)
+		users.POST("/me/api-keys", auth, recentAuth, middleware.RequireMFA(), apiKeyController.CreateAPIKey)
+		users.GET("/me/api-keys", auth, apiKeyController.ListAPIKeys)
+		users.DELETE("/me/api-keys/:id", auth, apiKeyController.RevokeAPIKey)
+		users.GET("/me/sessions", allowImpersonation, auth, sessionController.ListSessions)
+		users.DELETE("/me/sessions/:id", auth, sessionController.RevokeSession)
+		users.PUT("/profile", auth, controller.UpdateProfile)
+		users.DELETE("/delete", auth, recentAuth, controller.DeleteUser)
+
+		users.POST("/create-employee", auth, middleware.RequirePermission(models.PermissionRolesAssign), middleware.RequireMFA(), controller.CreateEmployee)
+		users.POST("/special", auth, middleware.RequirePermission(models.PermissionEmployeeCommands), middleware.RequireMFA(), controller.SpecialEmployeeEndpoint)
+		users.POST("/impersonate", auth, middleware.RequirePermission(models.PermissionUsersImpersonate), middleware.RequireMFA(), impersonationController.Impersonate)
+		users.GET("/lockouts/:id", keyAuth, middleware.RequireAPIKeyScope(usecase.ScopeLockoutsRead), middleware.RequirePermission(models.PermissionLockoutsRead), middleware.RequireMFA(), controller.GetLockout)
+		users.GET("/:id/sessions", auth, middleware.RequirePermission(models.PermissionSessionsRead), middleware.RequireMFA(), sessionController.ListCustomerSessions)
+		users.DELETE("/:id/sessions/:session_id", auth, middleware.RequirePermission(models.PermissionSessionsRevoke), middleware.RequireMFA(), sessionController.RevokeCustomerSession)
+		users.GET("/:id/roles", auth, middleware.RequirePermission(models.PermissionUsersRead), middleware.RequireMFA(), roleController.GetUserRoles)
+		users.PUT("/:id/roles", auth, middleware.RequirePermission(models.PermissionRolesAssign), middleware.RequireMFA(), roleController.SetUserRoles)
 
 	}
 }
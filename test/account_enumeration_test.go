@@ -0,0 +1,205 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timingSamples is how many requests of each kind are timed. Known accounts
+// get two failed logins each, which stays under LOGIN_BACKOFF_AFTER.
+const timingSamples = 30
+
+// timingZLimit is the two-sided 0.1% critical value of the standard normal
+// distribution.
+const timingZLimit = 3.29
+
+func median(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// timeInterleaved times a and b timingSamples times each, alternating
+// between them in a random order within each pair, so that drift in the
+// server's load or caches affects both alike.
+func timeInterleaved(a, b func(i int)) (aTimes, bTimes []time.Duration) {
+	timed := func(f func(int), i int) time.Duration {
+		start := time.Now()
+		f(i)
+		return time.Since(start)
+	}
+	for i := 0; i < timingSamples; i++ {
+		if rand.IntN(2) == 0 {
+			aTimes = append(aTimes, timed(a, i))
+			bTimes = append(bTimes, timed(b, i))
+		} else {
+			bTimes = append(bTimes, timed(b, i))
+			aTimes = append(aTimes, timed(a, i))
+		}
+	}
+	return aTimes, bTimes
+}
+
+// mannWhitneyZ is the z score of the Mann-Whitney U statistic of a against
+// b, using the normal approximation with a correction for ties. Unlike a
+// comparison of means it assumes nothing about the skewed distribution of
+// response times and isn't thrown off by the odd slow request.
+func mannWhitneyZ(a, b []time.Duration) float64 {
+	type sample struct {
+		d     time.Duration
+		fromA bool
+	}
+	all := make([]sample, 0, len(a)+len(b))
+	for _, d := range a {
+		all = append(all, sample{d, true})
+	}
+	for _, d := range b {
+		all = append(all, sample{d, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].d < all[j].d })
+
+	var rankSumA, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].d == all[i].d {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		tied := float64(j - i)
+		ties += tied*tied*tied - tied
+		i = j
+	}
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := rankSumA - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1)))
+	return (u - mean) / math.Sqrt(variance)
+}
+
+// assertSimilarTiming fails if a Mann-Whitney U test tells a and b apart at
+// the 0.1% level. Before the fix, the fast path skipped password hashing
+// or sending mail entirely, which this detects with a wide margin.
+func assertSimilarTiming(t *testing.T, a, b []time.Duration) {
+	t.Helper()
+	z := mannWhitneyZ(a, b)
+	t.Logf("median %v vs %v, z = %.2f", median(a), median(b), z)
+	assert.LessOrEqual(t, math.Abs(z), timingZLimit, "response times differ: median %v vs %v, z = %.2f", median(a), median(b), z)
+}
+
+func TestAccountEnumeration(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	password := "supersecure"
+
+	known := make([]string, timingSamples/2)
+	for i := range known {
+		known[i] = fmt.Sprintf("enum-%d+%s@test.com", i, timestamp)
+	}
+
+	t.Run("setup - register known accounts", func(t *testing.T) {
+		for _, email := range known {
+			resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": password})
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+	})
+
+	t.Run("registering a taken email looks like a new account", func(t *testing.T) {
+		fresh := "enum-fresh+" + timestamp + "@test.com"
+		resp, created := doJSON(t, "POST", "/users/register", "", map[string]string{"email": fresh, "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, taken := doJSON(t, "POST", "/users/register", "", map[string]string{"email": known[0], "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		createdUser, _ := created["user"].(map[string]any)
+		takenUser, _ := taken["user"].(map[string]any)
+		assert.Equal(t, known[0], takenUser["email"])
+		assert.NotEmpty(t, takenUser["ID"])
+		for field := range createdUser {
+			assert.Contains(t, takenUser, field)
+		}
+		assert.Len(t, takenUser, len(createdUser))
+
+		assert.True(t, strings.Contains(latestMail(t, known[0]), "You already have an account"))
+	})
+
+	t.Run("the owner can still log in after a duplicate registration", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": known[0], "password": password})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("failed logins take as long for unknown emails", func(t *testing.T) {
+		knownTimes, unknownTimes := timeInterleaved(func(i int) {
+			resp, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": known[i/2], "password": "wrong-password"})
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.Equal(t, "Invalid email or password", res["error"])
+		}, func(i int) {
+			email := fmt.Sprintf("enum-missing-%d+%s@test.com", i, timestamp)
+			resp, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": "wrong-password"})
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.Equal(t, "Invalid email or password", res["error"])
+		})
+		assertSimilarTiming(t, knownTimes, unknownTimes)
+	})
+
+	t.Run("unknown emails back off like known ones", func(t *testing.T) {
+		known := "enum-backoff+" + timestamp + "@test.com"
+		resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": known, "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		unknown := "enum-backoff-missing+" + timestamp + "@test.com"
+
+		for _, email := range []string{known, unknown} {
+			for i := 0; i < 3; i++ {
+				resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": "wrong-password"})
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			}
+			resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": "wrong-password"})
+			assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, email)
+			assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+		}
+	})
+
+	t.Run("registration takes as long for taken emails", func(t *testing.T) {
+		newTimes, takenTimes := timeInterleaved(func(i int) {
+			email := fmt.Sprintf("enum-new-%d+%s@test.com", i, timestamp)
+			resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": password})
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		}, func(i int) {
+			resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": known[i%len(known)], "password": password})
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		})
+		assertSimilarTiming(t, newTimes, takenTimes)
+	})
+
+	for _, path := range []string{"/users/password/forgot", "/users/login/magic-link"} {
+		t.Run(path+" takes as long for unknown emails", func(t *testing.T) {
+			knownTimes, unknownTimes := timeInterleaved(func(i int) {
+				resp, _ := doJSON(t, "POST", path, "", map[string]string{"email": known[i%len(known)]})
+				assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+			}, func(i int) {
+				email := fmt.Sprintf("enum-missing-%d+%s@test.com", i, timestamp)
+				resp, _ := doJSON(t, "POST", path, "", map[string]string{"email": email})
+				assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+			})
+			assertSimilarTiming(t, knownTimes, unknownTimes)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	employeeEmail := "api-keys-employee+" + timestamp + "@sort.com"
+	customerEmail := "api-keys-customer+" + timestamp + "@test.com"
+	password := "ApiKeys-Employee-42"
+
+	var employeeToken, customerToken, key, keyID string
+
+	t.Run("setup - create employee and customer", func(t *testing.T) {
+		credentials := map[string]string{"email": employeeEmail, "password": password}
+		resp, _ := createEmployee(t, employeeEmail, password, "admin")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		employeeToken, _ = res["token"].(string)
+
+		credentials = map[string]string{"email": customerEmail, "password": password}
+		resp, _ = doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		customerToken, _ = res["token"].(string)
+	})
+
+	t.Run("customers cannot create keys", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/api-keys", customerToken, map[string]any{
+			"name": "nope", "scopes": []string{"profile:read"}, "expires_in_days": 30,
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("unknown scope and long lifetime are rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/api-keys", employeeToken, map[string]any{
+			"name": "ops", "scopes": []string{"everything"}, "expires_in_days": 30,
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/me/api-keys", employeeToken, map[string]any{
+			"name": "ops", "scopes": []string{"profile:read"}, "expires_in_days": 10000,
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("employee creates a key", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/me/api-keys", employeeToken, map[string]any{
+			"name": "ops scripts", "scopes": []string{"profile:read"}, "expires_in_days": 30,
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		key, _ = res["key"].(string)
+		apiKey, _ := res["api_key"].(map[string]any)
+		keyID, _ = apiKey["id"].(string)
+		assert.NotEmpty(t, key)
+		assert.NotEmpty(t, keyID)
+	})
+
+	t.Run("key authenticates within its scopes", func(t *testing.T) {
+		resp, res := doJSON(t, "GET", "/users/me", "", nil, "Authorization", "ApiKey "+key)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		assert.Equal(t, employeeEmail, user["email"])
+
+		resp, _ = doJSON(t, "GET", "/oauth/clients", "", nil, "Authorization", "ApiKey "+key)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("key cannot manage keys or reach login-only endpoints", func(t *testing.T) {
+		resp, _ := doJSON(t, "GET", "/users/me/api-keys", "", nil, "Authorization", "ApiKey "+key)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "PUT", "/users/me/password", "", map[string]string{
+			"current_password": password, "new_password": "Another-Password-43",
+		}, "Authorization", "ApiKey "+key)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("list shows last use", func(t *testing.T) {
+		resp, res := doJSON(t, "GET", "/users/me/api-keys", employeeToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		keys, _ := res["api_keys"].([]any)
+		assert.Len(t, keys, 1)
+		apiKey, _ := keys[0].(map[string]any)
+		assert.Equal(t, keyID, apiKey["id"])
+		assert.NotNil(t, apiKey["last_used_at"])
+		assert.NotEmpty(t, apiKey["last_used_ip"])
+		assert.NotContains(t, apiKey, "key")
+	})
+
+	t.Run("revoked key is rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "DELETE", "/users/me/api-keys/"+keyID, employeeToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", "", nil, "Authorization", "ApiKey "+key)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "DELETE", "/users/me/api-keys/"+keyID, employeeToken, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captchaStubResponse must match the server's CAPTCHA_STUB_RESPONSE.
+func captchaStubResponse() string {
+	if response := os.Getenv("TEST_CAPTCHA_RESPONSE"); response != "" {
+		return response
+	}
+	return "test-captcha-pass"
+}
+
+// solveChallenge finds a solution whose SHA-256 with the token starts with
+// difficulty zero bits.
+func solveChallenge(token string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		sum := sha256.Sum256([]byte(token + ":" + solution))
+		zeros := 0
+		for _, b := range sum {
+			zeros += bits.LeadingZeros8(b)
+			if b != 0 {
+				break
+			}
+		}
+		if zeros >= difficulty {
+			return solution
+		}
+	}
+}
+
+func TestBotChallenge(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	domain := "bots-" + timestamp + ".example"
+	password := "supersecure"
+
+	var token, solution string
+	var difficulty int
+
+	credentials := func(email string) map[string]string {
+		return map[string]string{"email": email, "password": password}
+	}
+
+	readChallenge := func(t *testing.T, res map[string]any) {
+		challenge, _ := res["challenge"].(map[string]any)
+		assert.Equal(t, "proof_of_work", challenge["type"])
+		assert.Equal(t, "sha256", challenge["algorithm"])
+		token, _ = challenge["token"].(string)
+		d, _ := challenge["difficulty"].(float64)
+		difficulty = int(d)
+		assert.NotEmpty(t, token)
+		assert.Greater(t, difficulty, 0)
+	}
+
+	t.Run("a burst from one email domain trips the challenge", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			resp, res := doJSON(t, "POST", "/users/login", "", credentials(fmt.Sprintf("bot%d@%s", i, domain)))
+			if resp.StatusCode == http.StatusPreconditionRequired {
+				assert.Equal(t, "challenge required", res["error"])
+				assert.Equal(t, true, res["captcha"])
+				readChallenge(t, res)
+				return
+			}
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+		t.Fatal("challenge never required")
+	})
+
+	t.Run("other domains are unaffected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", credentials("nobody+"+timestamp+"@bots-other-"+timestamp+".example"))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("the same domain from another network is challenged too", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", credentials("elsewhere@"+domain), "X-Forwarded-For", "203.0.113.7")
+		assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+	})
+
+	t.Run("wrong solution gets a new challenge", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/register", "", credentials("wrong@"+domain), "X-Bot-Challenge", token, "X-Bot-Solution", "not-a-solution")
+		assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+		readChallenge(t, res)
+	})
+
+	t.Run("solved challenge lets the request through once", func(t *testing.T) {
+		solution = solveChallenge(token, difficulty)
+		headers := []string{"X-Bot-Challenge", token, "X-Bot-Solution", solution}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials("solved@"+domain), headers...)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/register", "", credentials("replayed@"+domain), headers...)
+		assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+	})
+
+	t.Run("CAPTCHA response is accepted instead", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials("captcha@"+domain), "X-Captcha-Response", "wrong")
+		assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/register", "", credentials("captcha@"+domain), "X-Captcha-Response", captchaStubResponse())
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
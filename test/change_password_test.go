@@ -0,0 +1,122 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePassword(t *testing.T) {
+	email := "change-password+" + time.Now().Format("150405") + "@test.com"
+	password := "supersecure"
+	newPassword := "copperlantern"
+
+	var token, refreshToken, newToken string
+
+	changePassword := func(t *testing.T, bearer string, payload map[string]string) (*http.Response, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("PUT", baseURL+"/users/me/password", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		var res map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	login := func(t *testing.T, password string) (*http.Response, map[string]string) {
+		payload := map[string]string{
+			"email":    email,
+			"password": password,
+		}
+		body, _ := json.Marshal(payload)
+		resp, err := http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		var res map[string]string
+		json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	t.Run("register user", func(t *testing.T) {
+		payload := map[string]string{
+			"email":    email,
+			"password": password,
+		}
+		body, _ := json.Marshal(payload)
+		resp, err := http.Post(baseURL+"/users/register", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("login to get token", func(t *testing.T) {
+		resp, res := login(t, password)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token = res["token"]
+		refreshToken = res["refresh_token"]
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("wrong current password is rejected", func(t *testing.T) {
+		resp, _ := changePassword(t, token, map[string]string{
+			"current_password": "not-my-password",
+			"new_password":     newPassword,
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("weak new password is rejected", func(t *testing.T) {
+		resp, res := changePassword(t, token, map[string]string{
+			"current_password": password,
+			"new_password":     "short",
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.NotEmpty(t, res["fields"])
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		resp, res := changePassword(t, token, map[string]string{
+			"current_password": password,
+			"new_password":     newPassword,
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		newToken, _ = res["token"].(string)
+		assert.NotEmpty(t, newToken)
+	})
+
+	t.Run("old tokens are revoked", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		resp, err = http.Post(baseURL+"/users/token/refresh", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("token from the change still works", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+newToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("only the new password logs in", func(t *testing.T) {
+		resp, _ := login(t, password)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = login(t, newPassword)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
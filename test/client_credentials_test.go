@@ -0,0 +1,135 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCredentials(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	email := "client-credentials+" + timestamp + "@test.com"
+	employeeEmail := "client-credentials-employee+" + timestamp + "@sort.com"
+	password := "Clients-Employee-42"
+
+	var userID, userToken, employeeToken, clientID, clientSecret, serviceToken string
+
+	requestToken := func(t *testing.T, secret, scope string) (*http.Response, map[string]any) {
+		form := url.Values{"grant_type": {"client_credentials"}}
+		if scope != "" {
+			form.Set("scope", scope)
+		}
+		req, _ := http.NewRequest("POST", baseURL+"/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(clientID, secret)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	get := func(t *testing.T, path, bearer string) *http.Response {
+		req, _ := http.NewRequest("GET", baseURL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("setup - register customer and employee", func(t *testing.T) {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, res := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		userID, _ = user["ID"].(string)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		userToken, _ = res["token"].(string)
+
+		credentials = map[string]string{"email": employeeEmail, "password": password}
+		resp, _ = createEmployee(t, employeeEmail, password, "admin")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		employeeToken, _ = res["token"].(string)
+	})
+
+	t.Run("customers cannot register clients", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/oauth/clients", userToken, map[string]any{"name": "nope", "scopes": []string{"users:read"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("employee registers a client", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/oauth/clients", employeeToken, map[string]any{
+			"name":   "orders-service " + timestamp,
+			"scopes": []string{"users:read", "orders:write"},
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		client, _ := res["client"].(map[string]any)
+		clientID, _ = client["client_id"].(string)
+		clientSecret, _ = res["client_secret"].(string)
+		assert.NotEmpty(t, clientID)
+		assert.NotEmpty(t, clientSecret)
+	})
+
+	t.Run("wrong secret is rejected", func(t *testing.T) {
+		resp, res := requestToken(t, "wrong-secret", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "invalid_client", res["error"])
+	})
+
+	t.Run("scope outside the client's scopes is rejected", func(t *testing.T) {
+		resp, res := requestToken(t, clientSecret, "users:write")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_scope", res["error"])
+	})
+
+	t.Run("token without the needed scope is forbidden", func(t *testing.T) {
+		resp, res := requestToken(t, clientSecret, "orders:write")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["access_token"].(string)
+		assert.Equal(t, http.StatusForbidden, get(t, "/internal/users/"+userID, token).StatusCode)
+	})
+
+	t.Run("client gets a token and looks up a user", func(t *testing.T) {
+		resp, res := requestToken(t, clientSecret, "users:read")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "Bearer", res["token_type"])
+		assert.Equal(t, "users:read", res["scope"])
+		serviceToken, _ = res["access_token"].(string)
+
+		resp = get(t, "/internal/users/"+userID, serviceToken)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var body map[string]map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		assert.Equal(t, email, body["user"]["email"])
+		assert.NotContains(t, body["user"], "password")
+	})
+
+	t.Run("user tokens cannot call service endpoints and vice versa", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get(t, "/internal/users/"+userID, userToken).StatusCode)
+		assert.Equal(t, http.StatusForbidden, get(t, "/users/me", serviceToken).StatusCode)
+	})
+
+	t.Run("revoked client cannot get tokens", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", baseURL+"/oauth/clients/"+clientID, nil)
+		req.Header.Set("Authorization", "Bearer "+employeeToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, res := requestToken(t, clientSecret, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "invalid_client", res["error"])
+
+		// The revoking instance drops its cached status immediately.
+		assert.Equal(t, http.StatusUnauthorized, get(t, "/internal/users/"+userID, serviceToken).StatusCode)
+	})
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// doJSON sends payload, unless nil, as a JSON request to the service and
+// decodes the JSON response. bearer, when set, is sent as a bearer token;
+// headers are further name and value pairs.
+func doJSON(t *testing.T, method, path, bearer string, payload any, headers ...string) (*http.Response, map[string]any) {
+	t.Helper()
+	var body []byte
+	if payload != nil {
+		body, _ = json.Marshal(payload)
+	}
+	req, _ := http.NewRequest(method, baseURL+path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	var res map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	return resp, res
+}
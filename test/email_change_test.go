@@ -0,0 +1,95 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailChange(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	oldEmail := "email-change+" + timestamp + "@test.com"
+	newEmail := "email-change-new+" + timestamp + "@test.com"
+	otherEmail := "email-change-other+" + timestamp + "@test.com"
+	password := "supersecure"
+
+	var token, confirmToken, revertToken string
+
+	login := func(t *testing.T, email string) *http.Response {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": password})
+		return resp
+	}
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		for _, email := range []string{oldEmail, otherEmail} {
+			resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": password})
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+
+		resp, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": oldEmail, "password": password})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("changing to the current email is rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/email", token, map[string]string{"email": oldEmail})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("changing to a registered email looks the same", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/email", token, map[string]string{"email": otherEmail})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Contains(t, latestMail(t, otherEmail), "already belongs to an account")
+	})
+
+	t.Run("request change mails both addresses", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/email", token, map[string]string{"email": newEmail})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		confirmToken = mailToken(t, newEmail)
+		assert.Contains(t, latestMail(t, oldEmail), newEmail)
+		revertToken = mailToken(t, oldEmail)
+		assert.NotEqual(t, confirmToken, revertToken)
+	})
+
+	t.Run("email is unchanged until confirmed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, login(t, oldEmail).StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, login(t, newEmail).StatusCode)
+	})
+
+	t.Run("confirm change", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/email/confirm", "", map[string]string{"token": confirmToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		assert.Equal(t, newEmail, user["email"])
+		assert.NotNil(t, user["email_verified_at"])
+
+		resp, _ = doJSON(t, "POST", "/users/email/confirm", "", map[string]string{"token": confirmToken})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		assert.Equal(t, http.StatusOK, login(t, newEmail).StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, login(t, oldEmail).StatusCode)
+	})
+
+	t.Run("old address reverts the change", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/email/revert", "", map[string]string{"token": revertToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/email/revert", "", map[string]string{"token": revertToken})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		assert.Equal(t, http.StatusOK, login(t, oldEmail).StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, login(t, newEmail).StatusCode)
+	})
+
+	t.Run("revert signs out every session", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
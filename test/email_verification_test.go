@@ -0,0 +1,106 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerification(t *testing.T) {
+	email := "verify+" + time.Now().Format("150405") + "@test.com"
+	password := "verifypassword"
+	credentials := map[string]string{"email": email, "password": password}
+
+	var verificationToken string
+
+	// emailVerifiedClaim decodes the access token payload without verifying it.
+	emailVerifiedClaim := func(t *testing.T, token string) any {
+		parts := strings.Split(token, ".")
+		assert.Len(t, parts, 3)
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.NoError(t, err)
+		var claims map[string]any
+		assert.NoError(t, json.Unmarshal(payload, &claims))
+		return claims["email_verified"]
+	}
+
+	// getMe returns the user object from /users/me.
+	getMe := func(t *testing.T, token string) map[string]any {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		user, _ := res["user"].(map[string]any)
+		return user
+	}
+
+	t.Run("registration sends a verification email", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		verificationToken = mailToken(t, email)
+		assert.NotEmpty(t, verificationToken)
+	})
+
+	t.Run("tokens of an unverified user say so", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["token"].(string)
+		assert.Equal(t, false, emailVerifiedClaim(t, token))
+		assert.Nil(t, getMe(t, token)["email_verified_at"])
+
+		resp, _ = doJSON(t, "POST", "/users/webauthn/register/begin", token, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("resend is throttled", func(t *testing.T) {
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/verify-email/resend", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		time.Sleep(200 * time.Millisecond)
+		assert.Equal(t, sent, mailCount(email))
+		assert.Equal(t, verificationToken, mailToken(t, email))
+	})
+
+	t.Run("resend for an unknown email looks the same", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/verify-email/resend", "", map[string]string{"email": "nobody+" + email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	})
+
+	t.Run("verify rejects an unknown token", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/users/verify-email?token=not-a-token")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("verify the email", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/users/verify-email?token=" + verificationToken)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("verification link works once", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/users/verify-email?token=" + verificationToken)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("new tokens carry the verified claim", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["token"].(string)
+		assert.Equal(t, true, emailVerifiedClaim(t, token))
+		assert.NotNil(t, getMe(t, token)["email_verified_at"])
+
+		resp, _ = doJSON(t, "POST", "/users/webauthn/register/begin", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
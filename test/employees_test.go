@@ -0,0 +1,51 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// adminCredentials returns the account the server creates from
+// BOOTSTRAP_ADMIN_EMAIL and BOOTSTRAP_ADMIN_PASSWORD.
+func adminCredentials() (string, string) {
+	email, password := os.Getenv("TEST_ADMIN_EMAIL"), os.Getenv("TEST_ADMIN_PASSWORD")
+	if email == "" {
+		email, password = "bootstrap-admin@sort.com", "Harbor-Lantern-Quiet-42"
+	}
+	return email, password
+}
+
+// loginAdmin logs in as the bootstrap admin.
+func loginAdmin(t *testing.T) string {
+	t.Helper()
+	email, password := adminCredentials()
+	body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+	resp, err := http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var res map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	token, _ := res["token"].(string)
+	return token
+}
+
+// createEmployee has the bootstrap admin create an employee holding roles.
+func createEmployee(t *testing.T, email, password string, roles ...string) (*http.Response, map[string]any) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"email": email, "password": password, "roles": roles})
+	req, _ := http.NewRequest("POST", baseURL+"/users/create-employee", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+loginAdmin(t))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	var res map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+	return resp, res
+}
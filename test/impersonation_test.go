@@ -0,0 +1,103 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonation(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	employeeEmail := "impersonation-employee+" + timestamp + "@sort.com"
+	customerEmail := "impersonation-customer+" + timestamp + "@test.com"
+	password := "Impersonate-Employee-42"
+
+	var employeeID, employeeToken, customerID, customerToken, token string
+
+	t.Run("setup - create employee and customer", func(t *testing.T) {
+		credentials := map[string]string{"email": employeeEmail, "password": password}
+		resp, res := createEmployee(t, employeeEmail, password, "support_agent")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		employeeID, _ = user["ID"].(string)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		employeeToken, _ = res["token"].(string)
+
+		credentials = map[string]string{"email": customerEmail, "password": password}
+		resp, res = doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ = res["user"].(map[string]any)
+		customerID, _ = user["ID"].(string)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		customerToken, _ = res["token"].(string)
+	})
+
+	t.Run("customers cannot impersonate", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/impersonate", customerToken, map[string]string{"user_id": employeeID, "reason": "curious"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("reason is required and employees cannot be impersonated", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/impersonate", employeeToken, map[string]string{"user_id": customerID})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/impersonate", employeeToken, map[string]string{"user_id": employeeID, "reason": "TICKET-1"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("employee impersonates the customer", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/impersonate", employeeToken, map[string]string{"user_id": customerID, "reason": "TICKET-42"})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		assert.NotEmpty(t, token)
+		assert.NotContains(t, res, "refresh_token")
+
+		resp, res = doJSON(t, "GET", "/users/me", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		assert.Equal(t, customerEmail, user["email"])
+	})
+
+	t.Run("token carries the act claim", func(t *testing.T) {
+		clientID, clientSecret := serviceCredentials()
+		form := url.Values{"token": {token}}
+		req, _ := http.NewRequest("POST", baseURL+"/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(clientID, clientSecret)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		assert.Equal(t, customerID, res["sub"])
+		assert.Equal(t, map[string]any{"sub": employeeID}, res["act"])
+	})
+
+	t.Run("sensitive endpoints refuse impersonation", func(t *testing.T) {
+		resp, _ := doJSON(t, "PUT", "/users/me/password", token, map[string]string{"current_password": password, "new_password": "Taken-Over-Account-43"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/me/email", token, map[string]string{"email": "attacker+" + timestamp + "@test.com"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "DELETE", "/users/delete", token, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("logout ends the impersonation", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/logout", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", token, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", customerToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
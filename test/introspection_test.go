@@ -0,0 +1,143 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sandroJayas/user-service/pkg/introspection"
+	"github.com/stretchr/testify/assert"
+)
+
+// serviceCredentials must match an entry of the server's SERVICE_CLIENTS.
+func serviceCredentials() (string, string) {
+	if id := os.Getenv("TEST_SERVICE_CLIENT_ID"); id != "" {
+		return id, os.Getenv("TEST_SERVICE_CLIENT_SECRET")
+	}
+	return "test-service", "test-service-secret"
+}
+
+func TestIntrospection(t *testing.T) {
+	email := "introspect+" + time.Now().Format("150405") + "@test.com"
+	password := "supersecure"
+	clientID, clientSecret := serviceCredentials()
+
+	var token, userID string
+
+	introspect := func(t *testing.T, id, secret, token string) (*http.Response, map[string]any) {
+		form := url.Values{"token": {token}}
+		req, _ := http.NewRequest("POST", baseURL+"/oauth/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if id != "" {
+			req.SetBasicAuth(id, secret)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+		resp, err := http.Post(baseURL+"/users/register", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var registered map[string]map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&registered)
+		userID, _ = registered["user"]["ID"].(string)
+
+		resp, err = http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var res map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		token = res["token"]
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("requires client credentials", func(t *testing.T) {
+		resp, _ := introspect(t, "", "", token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, res := introspect(t, clientID, "wrong-secret", token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "invalid_client", res["error"])
+	})
+
+	t.Run("active token", func(t *testing.T) {
+		resp, res := introspect(t, clientID, clientSecret, token)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, true, res["active"])
+		assert.Equal(t, false, res["revoked"])
+		assert.Equal(t, userID, res["sub"])
+		assert.Equal(t, "customer", res["account_type"])
+		assert.NotZero(t, res["exp"])
+	})
+
+	t.Run("garbage token is inactive", func(t *testing.T) {
+		resp, res := introspect(t, clientID, clientSecret, "not-a-token")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, map[string]any{"active": false}, res)
+	})
+
+	t.Run("client middleware accepts the token", func(t *testing.T) {
+		client := introspection.NewClient(introspection.Config{
+			Endpoint:     baseURL + "/oauth/introspect",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+		})
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/whoami", client.Middleware(), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("user_id"), "account_type": c.GetString("account_type")})
+		})
+
+		req := httptest.NewRequest("GET", "/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var res map[string]string
+		_ = json.NewDecoder(w.Body).Decode(&res)
+		assert.Equal(t, userID, res["user_id"])
+		assert.Equal(t, "customer", res["account_type"])
+
+		req = httptest.NewRequest("GET", "/whoami", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoked token is inactive", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", baseURL+"/users/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, res := introspect(t, clientID, clientSecret, token)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, false, res["active"])
+		assert.Equal(t, true, res["revoked"])
+
+		client := introspection.NewClient(introspection.Config{
+			Endpoint:     baseURL + "/oauth/introspect",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			CacheTTL:     -1,
+		})
+		result, err := client.Introspect(req.Context(), token)
+		assert.NoError(t, err)
+		assert.False(t, result.Active)
+		assert.True(t, result.Revoked)
+	})
+}
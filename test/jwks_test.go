@@ -0,0 +1,71 @@
+package test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKS(t *testing.T) {
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Alg string `json:"alg"`
+		} `json:"keys"`
+	}
+
+	t.Run("jwks is published", func(t *testing.T) {
+		resp, err := http.Get(baseURL + "/.well-known/jwks.json")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&jwks))
+		assert.NotNil(t, jwks.Keys)
+
+		for _, key := range jwks.Keys {
+			assert.NotEmpty(t, key.Kid)
+			assert.NotEqual(t, "oct", key.Kty, "symmetric keys must never be published")
+		}
+	})
+
+	t.Run("issued token kid is in the key set", func(t *testing.T) {
+		email := "jwks+" + time.Now().Format("150405") + "@test.com"
+		body, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": "jwkspassword",
+		})
+		resp, err := http.Post(baseURL+"/users/register", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, err = http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		headerJSON, err := base64.RawURLEncoding.DecodeString(strings.Split(res["token"], ".")[0])
+		assert.NoError(t, err)
+
+		var header map[string]string
+		_ = json.Unmarshal(headerJSON, &header)
+		if header["alg"] == "HS256" {
+			t.Skip("service is running with a shared secret")
+		}
+
+		found := false
+		for _, key := range jwks.Keys {
+			if key.Kid == header["kid"] {
+				found = true
+				assert.Equal(t, header["alg"], key.Alg)
+			}
+		}
+		assert.True(t, found, "token kid should be published in jwks")
+	})
+}
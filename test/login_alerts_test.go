@@ -0,0 +1,90 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAlerts(t *testing.T) {
+	email := "login-alerts+" + time.Now().Format("150405") + "@test.com"
+	password := "supersecure"
+	newPassword := "even-more-secure-42"
+	laptop := "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"
+	stranger := "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0"
+
+	var laptopToken, disownToken, resetToken string
+
+	credentials := map[string]string{"email": email, "password": password}
+
+	t.Run("setup - register", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials, "User-Agent", laptop)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		latestMail(t, email)
+	})
+
+	t.Run("first device is recorded silently", func(t *testing.T) {
+		before := mailCount(email)
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", laptop)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		laptopToken, _ = res["token"].(string)
+
+		resp, _ = doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", laptop)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, before, mailCount(email))
+	})
+
+	t.Run("new device triggers an alert", func(t *testing.T) {
+		before := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", stranger)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, before+1, mailCount(email))
+
+		mail := latestMail(t, email)
+		assert.Contains(t, mail, "New sign-in to your account")
+		assert.Contains(t, mail, "Firefox on Windows")
+		disownToken = mailToken(t, email)
+
+		resp, _ = doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", stranger)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, before+1, mailCount(email))
+	})
+
+	t.Run("this wasn't me locks the account down", func(t *testing.T) {
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/login/disown", "", map[string]string{"token": disownToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		awaitMail(t, email, sent)
+
+		resp, _ = doJSON(t, "GET", "/users/me", laptopToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", laptop)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/login/disown", "", map[string]string{"token": disownToken})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("login links are refused until the password is reset", func(t *testing.T) {
+		assert.Contains(t, latestMail(t, email), "Reset your password")
+		resetToken = mailToken(t, email)
+
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/login/magic-link", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+		resp, _ = doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": mailToken(t, email)}, "User-Agent", laptop)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("password reset unlocks password logins", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": resetToken, "password": newPassword})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": newPassword}, "User-Agent", laptop)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
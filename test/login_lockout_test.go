@@ -0,0 +1,101 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockout(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	email := "lockout+" + timestamp + "@test.com"
+	employeeEmail := "lockout-employee+" + timestamp + "@sort.com"
+	password := "Lockout-Employee-42"
+
+	var userID, employeeToken string
+
+	login := func(t *testing.T, password string) *http.Response {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": password})
+		return resp
+	}
+
+	getLockout := func(t *testing.T) (*http.Response, map[string]any) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/lockouts/"+userID, nil)
+		req.Header.Set("Authorization", "Bearer "+employeeToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	t.Run("setup - register customer and employee", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		userID, _ = user["ID"].(string)
+		assert.NotEmpty(t, userID)
+
+		credentials := map[string]string{"email": employeeEmail, "password": password}
+		resp, _ = createEmployee(t, employeeEmail, password, "support_agent")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		employeeToken, _ = res["token"].(string)
+	})
+
+	t.Run("a couple of typos do not block the user", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, login(t, "wrongpass1").StatusCode)
+		assert.Equal(t, http.StatusOK, login(t, password).StatusCode)
+	})
+
+	t.Run("success resets the counter", func(t *testing.T) {
+		resp, res := getLockout(t)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, float64(0), res["failed_attempts"])
+		assert.Equal(t, false, res["locked"])
+	})
+
+	t.Run("repeated failures back off", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login(t, "wrongpass2").StatusCode)
+		}
+
+		// Even the right password is refused during the backoff.
+		resp := login(t, password)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("employees can see the lockout state", func(t *testing.T) {
+		resp, res := getLockout(t)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, float64(3), res["failed_attempts"])
+		assert.Equal(t, true, res["locked"])
+		assert.NotNil(t, res["locked_until"])
+	})
+
+	t.Run("login works again once the backoff passed", func(t *testing.T) {
+		time.Sleep(1100 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, login(t, password).StatusCode)
+
+		_, res := getLockout(t)
+		assert.Equal(t, float64(0), res["failed_attempts"])
+	})
+
+	t.Run("customers cannot see lockout state", func(t *testing.T) {
+		_, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": password})
+		customerToken, _ := res["token"].(string)
+		assert.NotEmpty(t, customerToken)
+
+		req, _ := http.NewRequest("GET", baseURL+"/users/lockouts/"+userID, nil)
+		req.Header.Set("Authorization", "Bearer "+customerToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
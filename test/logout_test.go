@@ -0,0 +1,92 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogout(t *testing.T) {
+	email := "logout+" + time.Now().Format("150405") + "@test.com"
+	password := "logoutpass"
+
+	login := func(t *testing.T) map[string]string {
+		body, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		resp, err := http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		assert.NotEmpty(t, res["token"])
+		return res
+	}
+
+	me := func(t *testing.T, token string) int {
+		resp, _ := doJSON(t, "GET", "/users/me", token, nil)
+		return resp.StatusCode
+	}
+
+	t.Run("setup - register", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		resp, err := http.Post(baseURL+"/users/register", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("logout revokes the access and refresh token", func(t *testing.T) {
+		session := login(t)
+		assert.Equal(t, http.StatusOK, me(t, session["token"]))
+
+		resp, _ := doJSON(t, "POST", "/users/logout", session["token"], map[string]string{
+			"refresh_token": session["refresh_token"],
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		assert.Equal(t, http.StatusUnauthorized, me(t, session["token"]))
+		resp, _ = doJSON(t, "POST", "/users/token/refresh", "", map[string]string{
+			"refresh_token": session["refresh_token"],
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("logout leaves other sessions alone", func(t *testing.T) {
+		first := login(t)
+		second := login(t)
+
+		resp, _ := doJSON(t, "POST", "/users/logout", first["token"], nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, me(t, first["token"]))
+		assert.Equal(t, http.StatusOK, me(t, second["token"]))
+	})
+
+	t.Run("logout-all revokes every session", func(t *testing.T) {
+		first := login(t)
+		second := login(t)
+
+		resp, _ := doJSON(t, "POST", "/users/logout-all", first["token"], nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, me(t, first["token"]))
+		assert.Equal(t, http.StatusUnauthorized, me(t, second["token"]))
+
+		resp, _ = doJSON(t, "POST", "/users/token/refresh", "", map[string]string{
+			"refresh_token": second["refresh_token"],
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("login after logout-all works", func(t *testing.T) {
+		session := login(t)
+		assert.Equal(t, http.StatusOK, me(t, session["token"]))
+	})
+}
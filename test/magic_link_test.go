@@ -0,0 +1,116 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sandroJayas/user-service/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLinkLogin(t *testing.T) {
+	email := "magic+" + time.Now().Format("150405") + "@test.com"
+	password := "magicpassword"
+
+	var token, linkToken, secret string
+
+	// claims decodes the access token payload without verifying it.
+	claims := func(t *testing.T, token string) map[string]any {
+		parts := strings.Split(token, ".")
+		assert.Len(t, parts, 3)
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.NoError(t, err)
+		var claims map[string]any
+		assert.NoError(t, json.Unmarshal(payload, &claims))
+		return claims
+	}
+
+	t.Run("setup - register", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("unknown email gets the same response", func(t *testing.T) {
+		sent := mailCount(email)
+		resp, known := doJSON(t, "POST", "/users/login/magic-link", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+		linkToken = mailToken(t, email)
+
+		resp, unknown := doJSON(t, "POST", "/users/login/magic-link", "", map[string]string{"email": "nobody+" + email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Equal(t, known, unknown)
+	})
+
+	t.Run("consume rejects an unknown token", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": "not-a-token"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("a new link invalidates the previous one", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/login/magic-link", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+		previous := linkToken
+		linkToken = mailToken(t, email)
+		assert.NotEqual(t, previous, linkToken)
+
+		resp, _ = doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": previous})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("consume logs in and verifies the email", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": linkToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, res["refresh_token"])
+
+		c := claims(t, token)
+		assert.Equal(t, []any{"email"}, c["amr"])
+		assert.Equal(t, true, c["email_verified"])
+	})
+
+	t.Run("link works only once", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": linkToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("setup - enable two-factor authentication", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/me/mfa/totp", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		secret, _ = res["secret"].(string)
+
+		code, err := utils.TOTPCode(secret, utils.TOTPCounter(time.Now()))
+		assert.NoError(t, err)
+		resp, _ = doJSON(t, "POST", "/users/me/mfa/totp/confirm", token, map[string]string{"code": code})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("link does not bypass two-factor authentication", func(t *testing.T) {
+		time.Sleep(10 * time.Millisecond)
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/login/magic-link", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+
+		resp, res := doJSON(t, "POST", "/users/login/magic-link/consume", "", map[string]string{"token": mailToken(t, email)})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, true, res["mfa_required"])
+		assert.Nil(t, res["token"])
+		mfaToken, _ := res["mfa_token"].(string)
+
+		// The confirmation consumed the current step, so use the next one.
+		code, _ := utils.TOTPCode(secret, utils.TOTPCounter(time.Now())+1)
+		resp, res = doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		accessToken, _ := res["token"].(string)
+		assert.Equal(t, []any{"email", "otp"}, claims(t, accessToken)["amr"])
+	})
+}
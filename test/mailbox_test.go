@@ -0,0 +1,65 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// outboxDir is where the server's log mailer writes messages, see
+// MAIL_OUTBOX_DIR. Tests run from ./test while the server runs from the root.
+func outboxDir() string {
+	if dir := os.Getenv("MAIL_OUTBOX_DIR"); dir != "" {
+		return dir
+	}
+	return "../tmp/outbox"
+}
+
+// mailCount is the number of emails sent to the recipient so far.
+func mailCount(to string) int {
+	files, _ := filepath.Glob(filepath.Join(outboxDir(), to, "*.eml"))
+	return len(files)
+}
+
+// awaitMail waits briefly for the recipient to have more than count emails.
+// Endpoints that must not reveal whether an account exists send their links
+// in the background, after the response.
+func awaitMail(t *testing.T, to string, count int) {
+	t.Helper()
+	for i := 0; i < 20; i++ {
+		if mailCount(to) > count {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("no new email sent to %s", to)
+}
+
+// latestMail returns the newest email sent to the recipient, waiting briefly
+// for it to arrive.
+func latestMail(t *testing.T, to string) string {
+	t.Helper()
+	awaitMail(t, to, 0)
+	files, _ := filepath.Glob(filepath.Join(outboxDir(), to, "*.eml"))
+	sort.Strings(files)
+	content, err := os.ReadFile(files[len(files)-1])
+	assert.NoError(t, err)
+	return string(content)
+}
+
+var linkTokenPattern = regexp.MustCompile(`[?&]token=([A-Za-z0-9_\-%]+)`)
+
+// mailToken extracts the token query parameter from the newest email's link.
+func mailToken(t *testing.T, to string) string {
+	t.Helper()
+	match := linkTokenPattern.FindStringSubmatch(latestMail(t, to))
+	if match == nil {
+		t.Fatalf("no link token in email to %s", to)
+	}
+	return match[1]
+}
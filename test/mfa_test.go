@@ -0,0 +1,113 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sandroJayas/user-service/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPLogin(t *testing.T) {
+	email := "mfa+" + time.Now().Format("150405") + "@test.com"
+	password := "mfapassword"
+
+	var token, secret, mfaToken string
+
+	credentials := map[string]string{"email": email, "password": password}
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("enrol returns secret and otpauth uri", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/me/mfa/totp", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		secret, _ = res["secret"].(string)
+		assert.NotEmpty(t, secret)
+		assert.Contains(t, res["otpauth_uri"], "otpauth://totp/")
+	})
+
+	t.Run("login still skips mfa before confirmation", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, res["token"])
+	})
+
+	t.Run("confirm rejects a wrong code", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/me/mfa/totp/confirm", token, map[string]string{"code": "000000"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("confirm with a valid code", func(t *testing.T) {
+		code, err := utils.TOTPCode(secret, utils.TOTPCounter(time.Now()))
+		assert.NoError(t, err)
+		resp, _ := doJSON(t, "POST", "/users/me/mfa/totp/confirm", token, map[string]string{"code": code})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("login returns an mfa challenge", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, true, res["mfa_required"])
+		assert.Nil(t, res["token"])
+		mfaToken, _ = res["mfa_token"].(string)
+		assert.NotEmpty(t, mfaToken)
+	})
+
+	t.Run("mfa token is not an access token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+mfaToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("wrong code is rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	// The confirmation consumed the current step, so use the next one, which
+	// is still inside the drift window.
+	code, _ := utils.TOTPCode(secret, utils.TOTPCounter(time.Now())+1)
+
+	t.Run("valid code completes login", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, res["token"])
+		assert.NotEmpty(t, res["refresh_token"])
+	})
+
+	t.Run("code cannot be replayed", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("mfa token cannot be exchanged twice", func(t *testing.T) {
+		unused, _ := utils.TOTPCode(secret, utils.TOTPCounter(time.Now())-1)
+		resp, _ := doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": unused})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("wrong codes count towards the lockout", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		mfaToken, _ = res["mfa_token"].(string)
+
+		for i := 0; i < 3; i++ {
+			resp, _ = doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+		resp, _ = doJSON(t, "POST", "/users/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	})
+}
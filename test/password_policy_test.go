@@ -0,0 +1,72 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	email := "policy+" + timestamp + "@test.com"
+
+	violationCodes := func(res map[string]any) []string {
+		fields, _ := res["fields"].([]any)
+		var codes []string
+		for _, field := range fields {
+			violation, _ := field.(map[string]any)
+			assert.Equal(t, "password", violation["field"])
+			assert.NotEmpty(t, violation["message"])
+			code, _ := violation["code"].(string)
+			codes = append(codes, code)
+		}
+		return codes
+	}
+
+	t.Run("short password is rejected", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": "x9!"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, violationCodes(res), "too_short")
+	})
+
+	t.Run("breached password is rejected", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": "password123"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, violationCodes(res), "breached")
+	})
+
+	t.Run("password containing the email is rejected", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": "my-" + email})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, violationCodes(res), "contains_email")
+	})
+
+	t.Run("employee policy is stricter", func(t *testing.T) {
+		resp, res := createEmployee(t, "policy-employee+"+timestamp+"@sort.com", "customergrade")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		codes := violationCodes(res)
+		assert.Contains(t, codes, "too_few_character_classes")
+	})
+
+	t.Run("acceptable password registers", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/register", "", map[string]string{"email": email, "password": "quietharbor"})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("rejected reset keeps the reset link usable", func(t *testing.T) {
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/password/forgot", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+		resetToken := mailToken(t, email)
+
+		resp, res := doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": resetToken, "password": "password123"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, violationCodes(res), "breached")
+
+		resp, _ = doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": resetToken, "password": "lanternfield"})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordReset(t *testing.T) {
+	email := "reset+" + time.Now().Format("150405") + "@test.com"
+	password := "oldpassword"
+	newPassword := "brandnewpassword"
+
+	var token, refreshToken, resetToken string
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		refreshToken, _ = res["refresh_token"].(string)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("forgot password for an unknown email looks the same", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/password/forgot", "", map[string]string{"email": "nobody+" + email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.NotEmpty(t, res["message"])
+	})
+
+	t.Run("requesting a new link invalidates the previous one", func(t *testing.T) {
+		sent := mailCount(email)
+		resp, _ := doJSON(t, "POST", "/users/password/forgot", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent)
+		first := mailToken(t, email)
+
+		time.Sleep(10 * time.Millisecond)
+		resp, _ = doJSON(t, "POST", "/users/password/forgot", "", map[string]string{"email": email})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		awaitMail(t, email, sent+1)
+		resetToken = mailToken(t, email)
+		assert.NotEqual(t, first, resetToken)
+
+		resp, _ = doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": first, "password": newPassword})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("reset rejects an unknown token", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": "not-a-token", "password": newPassword})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("reset sets the new password", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": resetToken, "password": newPassword})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("reset token cannot be reused", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/password/reset", "", map[string]string{"token": resetToken, "password": "yetanotherpassword"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("existing sessions are revoked", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/token/refresh", "", map[string]string{"refresh_token": refreshToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("only the new password works", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": password})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": newPassword})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smsOutboxDir is where the server's log SMS sender writes messages, see
+// SMS_OUTBOX_DIR.
+func smsOutboxDir() string {
+	if dir := os.Getenv("SMS_OUTBOX_DIR"); dir != "" {
+		return dir
+	}
+	return "../tmp/sms"
+}
+
+var smsCodePattern = regexp.MustCompile(`\b(\d{6})\b`)
+
+// latestSMSCode returns the code in the newest text sent to the number,
+// waiting briefly for it to arrive.
+func latestSMSCode(t *testing.T, to string) string {
+	t.Helper()
+	dir := filepath.Join(smsOutboxDir(), to)
+	for i := 0; i < 20; i++ {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if len(files) > 0 {
+			sort.Strings(files)
+			content, err := os.ReadFile(files[len(files)-1])
+			assert.NoError(t, err)
+			match := smsCodePattern.FindStringSubmatch(string(content))
+			if match == nil {
+				t.Fatalf("no code in text to %s", to)
+			}
+			return match[1]
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("no text sent to %s", to)
+	return ""
+}
+
+func TestPhoneVerification(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	password := "supersecure"
+
+	signUp := func(t *testing.T, email string) string {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["token"].(string)
+		return token
+	}
+
+	setPhone := func(t *testing.T, token, phone string) map[string]any {
+		resp, res := doJSON(t, "PUT", "/users/profile", token, map[string]string{
+			"first_name":     "Phone",
+			"last_name":      "Tester",
+			"address_line_1": "1 Dial St",
+			"city":           "Sortville",
+			"postal_code":    "00000",
+			"country":        "Sortland",
+			"phone_number":   phone,
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		return user
+	}
+
+	t.Run("verify, then changing the number clears verification", func(t *testing.T) {
+		token := signUp(t, "phone+"+timestamp+"@test.com")
+		phone := "+1555" + timestamp + "1"
+
+		resp, _ := doJSON(t, "POST", "/users/me/phone/code", token, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "no phone number yet")
+
+		setPhone(t, token, phone)
+		resp, _ = doJSON(t, "POST", "/users/me/phone/code", token, nil)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		code := latestSMSCode(t, phone)
+
+		resp, _ = doJSON(t, "POST", "/users/me/phone/code", token, nil)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+		resp, res := doJSON(t, "POST", "/users/me/phone/verify", token, map[string]string{"code": code})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		assert.NotNil(t, user["phone_verified_at"])
+
+		resp, _ = doJSON(t, "POST", "/users/me/phone/verify", token, map[string]string{"code": code})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "codes are single use")
+
+		user = setPhone(t, token, phone)
+		assert.NotNil(t, user["phone_verified_at"], "saving the same number keeps verification")
+		user = setPhone(t, token, "+1555"+timestamp+"2")
+		assert.Nil(t, user["phone_verified_at"])
+	})
+
+	t.Run("wrong codes use up the attempts", func(t *testing.T) {
+		token := signUp(t, "phone-attempts+"+timestamp+"@test.com")
+		phone := "+1555" + timestamp + "3"
+		setPhone(t, token, phone)
+
+		resp, _ := doJSON(t, "POST", "/users/me/phone/code", token, nil)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		code := latestSMSCode(t, phone)
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+
+		for i := 0; i < 5; i++ {
+			resp, _ = doJSON(t, "POST", "/users/me/phone/verify", token, map[string]string{"code": wrong})
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+		resp, _ = doJSON(t, "POST", "/users/me/phone/verify", token, map[string]string{"code": code})
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+}
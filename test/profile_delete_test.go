@@ -65,7 +65,7 @@ func TestDeleteUser(t *testing.T) {
 
 		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
 	})
 
 	t.Run("register same user", func(t *testing.T) {
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// authTime reads the auth_time claim of a JWT without verifying it.
+func authTime(t *testing.T, token string) int64 {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a JWT: %q", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+	var claims map[string]any
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	value, _ := claims["auth_time"].(float64)
+	return int64(value)
+}
+
+func TestReauth(t *testing.T) {
+	email := "reauth+" + time.Now().Format("150405") + "@test.com"
+	password := "supersecure"
+
+	var token, refreshToken string
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		refreshToken, _ = res["refresh_token"].(string)
+	})
+
+	t.Run("login and refresh carry the login's auth_time", func(t *testing.T) {
+		loggedInAt := authTime(t, token)
+		assert.InDelta(t, time.Now().Unix(), loggedInAt, 60)
+
+		time.Sleep(1100 * time.Millisecond)
+		resp, res := doJSON(t, "POST", "/users/token/refresh", "", map[string]string{"refresh_token": refreshToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		refreshed, _ := res["token"].(string)
+		assert.Equal(t, loggedInAt, authTime(t, refreshed))
+		token = refreshed
+	})
+
+	t.Run("wrong password or missing proof is rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/reauth", token, map[string]string{"password": "not-my-password"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/reauth", token, map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/reauth", "", map[string]string{"password": password})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("code is rejected without an authenticator", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/reauth", token, map[string]string{"code": "123456"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("wrong codes count towards the lockout", func(t *testing.T) {
+		// The wrong password and code above were the first two failures.
+		resp, _ := doJSON(t, "POST", "/users/reauth", token, map[string]string{"code": "654321"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/reauth", token, map[string]string{"code": "654321"})
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+		time.Sleep(1100 * time.Millisecond)
+	})
+
+	t.Run("password re-authentication moves auth_time forward", func(t *testing.T) {
+		before := authTime(t, token)
+		resp, res := doJSON(t, "POST", "/users/reauth", token, map[string]string{"password": password})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		elevated, _ := res["token"].(string)
+		assert.Greater(t, authTime(t, elevated), before)
+
+		resp, _ = doJSON(t, "DELETE", "/users/delete", elevated, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
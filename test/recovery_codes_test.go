@@ -0,0 +1,144 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	stamp := time.Now().Format("150405")
+	email := "recovery+" + stamp + "@test.com"
+	newEmail := "recovered+" + stamp + "@test.com"
+	password := "recoverypass"
+	newPassword := "brandnewpass"
+
+	var token, recoveryToken string
+	var codes []string
+
+	remaining := func(t *testing.T) float64 {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		count, _ := res["recovery_codes_remaining"].(float64)
+		return count
+	}
+
+	t.Run("setup - register and login", func(t *testing.T) {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+	})
+
+	t.Run("no codes before generation", func(t *testing.T) {
+		assert.Equal(t, float64(0), remaining(t))
+	})
+
+	t.Run("generate codes", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/me/recovery-codes", token, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		raw, _ := res["recovery_codes"].([]any)
+		for _, code := range raw {
+			codes = append(codes, code.(string))
+		}
+		assert.Len(t, codes, 10)
+		assert.Equal(t, float64(10), remaining(t))
+	})
+
+	t.Run("wrong code is rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/recover", "", map[string]string{"email": email, "code": "aaaaa-aaaaa"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("valid code issues a recovery token", func(t *testing.T) {
+		// Codes are accepted regardless of case and dashes.
+		typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+		resp, res := doJSON(t, "POST", "/users/recover", "", map[string]string{"email": email, "code": typed})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		recoveryToken, _ = res["recovery_token"].(string)
+		assert.NotEmpty(t, recoveryToken)
+		assert.Equal(t, float64(9), remaining(t))
+	})
+
+	t.Run("used code cannot be redeemed again", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/recover", "", map[string]string{"email": email, "code": codes[0]})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("recovery token is not an access token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+recoveryToken)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("access token cannot reset credentials", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/recover/reset", token, map[string]string{"password": newPassword})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("reset password and email", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/recover/reset", recoveryToken, map[string]string{
+			"password": newPassword,
+			"email":    newEmail,
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, true, res["email_change_pending"])
+		user, _ := res["user"].(map[string]any)
+		assert.Equal(t, email, user["email"])
+	})
+
+	t.Run("recovery token is single use", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/recover/reset", recoveryToken, map[string]string{"password": "anotherpass"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("old sessions are revoked", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("new email needs confirmation", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": newEmail, "password": newPassword})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, latestMail(t, email), newEmail)
+
+		resp, res := doJSON(t, "POST", "/users/email/confirm", "", map[string]string{"token": mailToken(t, newEmail)})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		assert.Equal(t, newEmail, user["email"])
+	})
+
+	t.Run("login with new credentials", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": newEmail, "password": newPassword})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("wrong codes back off, for unknown emails too", func(t *testing.T) {
+		for _, address := range []string{newEmail, "recovery-missing+" + stamp + "@test.com"} {
+			for i := 0; i < 3; i++ {
+				resp, _ := doJSON(t, "POST", "/users/recover", "", map[string]string{"email": address, "code": "aaaaa-aaaaa"})
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			}
+			resp, _ := doJSON(t, "POST", "/users/recover", "", map[string]string{"email": address, "code": codes[1]})
+			assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, address)
+		}
+	})
+}
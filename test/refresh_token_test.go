@@ -0,0 +1,80 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken(t *testing.T) {
+	email := "refresh+" + time.Now().Format("150405") + "@test.com"
+	password := "refreshpass"
+
+	var firstRefresh, secondRefresh string
+
+	refresh := func(t *testing.T, token string) (*http.Response, map[string]string) {
+		body, _ := json.Marshal(map[string]string{"refresh_token": token})
+		resp, err := http.Post(baseURL+"/users/token/refresh", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		var res map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		return resp, res
+	}
+
+	t.Run("setup - register", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		resp, err := http.Post(baseURL+"/users/register", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("login returns both tokens", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		resp, err := http.Post(baseURL+"/users/login", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]string
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		assert.NotEmpty(t, res["token"])
+		assert.NotEmpty(t, res["token_expires_at"])
+		assert.NotEmpty(t, res["refresh_token"])
+		assert.NotEmpty(t, res["refresh_token_expires_at"])
+		firstRefresh = res["refresh_token"]
+	})
+
+	t.Run("refresh rotates the token", func(t *testing.T) {
+		resp, res := refresh(t, firstRefresh)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, res["token"])
+		assert.NotEmpty(t, res["refresh_token"])
+		assert.NotEqual(t, firstRefresh, res["refresh_token"])
+		secondRefresh = res["refresh_token"]
+	})
+
+	t.Run("reusing a rotated token is rejected", func(t *testing.T) {
+		resp, _ := refresh(t, firstRefresh)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("reuse revokes the whole family", func(t *testing.T) {
+		resp, _ := refresh(t, secondRefresh)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("unknown token is rejected", func(t *testing.T) {
+		resp, _ := refresh(t, "not-a-real-token")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,181 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenPermissions reads the permissions claim of a JWT without verifying
+// it.
+func tokenPermissions(t *testing.T, token string) []string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a JWT: %q", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+	var claims struct {
+		Permissions []string `json:"permissions"`
+	}
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	return claims.Permissions
+}
+
+func TestRoles(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	staffEmail := "roles-staff+" + timestamp + "@sort.com"
+	agentEmail := "roles-agent+" + timestamp + "@sort.com"
+	customerEmail := "roles-customer+" + timestamp + "@test.com"
+	password := "SuperSecure123!"
+
+	var adminToken, staffToken, customerToken string
+	var adminID, staffID, customerID string
+
+	login := func(t *testing.T, email string) string {
+		resp, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": password})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["token"].(string)
+		return token
+	}
+
+	userID := func(res map[string]any) string {
+		user, _ := res["user"].(map[string]any)
+		id, _ := user["ID"].(string)
+		return id
+	}
+
+	roleNames := func(res map[string]any) []string {
+		roles, _ := res["roles"].([]any)
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			entry, _ := role.(map[string]any)
+			name, _ := entry["name"].(string)
+			names = append(names, name)
+		}
+		return names
+	}
+
+	t.Run("setup - accounts", func(t *testing.T) {
+		adminToken = loginAdmin(t)
+		resp, res := doJSON(t, "GET", "/users/me", adminToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		adminID = userID(res)
+
+		resp, res = createEmployee(t, staffEmail, password)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		staffID = userID(res)
+		staffToken = login(t, staffEmail)
+
+		resp, res = doJSON(t, "POST", "/users/register", "", map[string]string{"email": customerEmail, "password": password})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		customerID = userID(res)
+		customerToken = login(t, customerEmail)
+	})
+
+	t.Run("customers get the customer role and employees start without one", func(t *testing.T) {
+		assert.Contains(t, tokenPermissions(t, adminToken), "roles:assign")
+		assert.Empty(t, tokenPermissions(t, customerToken))
+		assert.Empty(t, tokenPermissions(t, staffToken))
+
+		resp, res := doJSON(t, "GET", "/users/"+customerID+"/roles", adminToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"customer"}, roleNames(res))
+
+		resp, res = doJSON(t, "GET", "/users/"+staffID+"/roles", adminToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, roleNames(res))
+	})
+
+	t.Run("creating employees requires roles:assign", func(t *testing.T) {
+		credentials := map[string]string{"email": "roles-intruder+" + timestamp + "@sort.com", "password": password}
+		resp, _ := doJSON(t, "POST", "/users/create-employee", "", credentials)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/create-employee", customerToken, credentials)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/create-employee", staffToken, credentials)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("employees can be created with roles", func(t *testing.T) {
+		resp, _ := createEmployee(t, agentEmail, password, "customer")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp, _ = createEmployee(t, agentEmail, password, "no-such-role")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = createEmployee(t, agentEmail, password, "support_agent")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Contains(t, tokenPermissions(t, login(t, agentEmail)), "users:impersonate")
+	})
+
+	t.Run("roles are listed with their permissions", func(t *testing.T) {
+		resp, res := doJSON(t, "GET", "/roles", adminToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Subset(t, roleNames(res), []string{"admin", "customer", "employee", "support_agent", "warehouse_staff"})
+
+		resp, _ = doJSON(t, "GET", "/roles", customerToken, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("assigning a role signs the user out", func(t *testing.T) {
+		resp, res := doJSON(t, "PUT", "/users/"+staffID+"/roles", adminToken, map[string]any{"roles": []string{"warehouse_staff"}})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"warehouse_staff"}, roleNames(res))
+
+		resp, _ = doJSON(t, "GET", "/users/me", staffToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("the next login carries only the new permissions", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/login", "", map[string]string{"email": staffEmail, "password": password})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		staffToken, _ = res["token"].(string)
+		assert.Equal(t, []string{"users:read"}, tokenPermissions(t, staffToken))
+
+		resp, _ = doJSON(t, "GET", "/users/"+adminID+"/roles", staffToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "POST", "/users/special", staffToken, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp, _ = doJSON(t, "PUT", "/users/"+customerID+"/roles", staffToken, map[string]any{"roles": []string{"customer"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("staff roles cannot go to customers", func(t *testing.T) {
+		resp, _ := doJSON(t, "PUT", "/users/"+customerID+"/roles", adminToken, map[string]any{"roles": []string{"support_agent"}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unknown roles are rejected", func(t *testing.T) {
+		resp, _ := doJSON(t, "PUT", "/users/"+staffID+"/roles", adminToken, map[string]any{"roles": []string{"no-such-role"}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("users cannot change their own roles", func(t *testing.T) {
+		resp, _ := doJSON(t, "PUT", "/users/"+adminID+"/roles", adminToken, map[string]any{"roles": []string{"admin"}})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("introspection returns permissions", func(t *testing.T) {
+		clientID, clientSecret := serviceCredentials()
+		req, _ := http.NewRequest("POST", baseURL+"/oauth/introspect", strings.NewReader("token="+staffToken))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(clientID, clientSecret)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&res)
+		assert.Equal(t, []any{"users:read"}, res["permissions"])
+	})
+}
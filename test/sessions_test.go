@@ -0,0 +1,131 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	timestamp := time.Now().Format("150405")
+	customerEmail := "sessions-customer+" + timestamp + "@test.com"
+	employeeEmail := "sessions-employee+" + timestamp + "@sort.com"
+	password := "Sessions-Employee-42"
+
+	var customerID, employeeID, employeeToken, laptopToken, laptopRefresh, phoneToken, phoneRefresh, phoneSessionID string
+
+	sessions := func(t *testing.T, path, bearer string) []map[string]any {
+		resp, res := doJSON(t, "GET", path, bearer, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		raw, _ := res["sessions"].([]any)
+		list := make([]map[string]any, 0, len(raw))
+		for _, item := range raw {
+			session, _ := item.(map[string]any)
+			list = append(list, session)
+		}
+		return list
+	}
+
+	t.Run("setup - register customer and employee", func(t *testing.T) {
+		credentials := map[string]string{"email": customerEmail, "password": password}
+		resp, res := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ := res["user"].(map[string]any)
+		customerID, _ = user["ID"].(string)
+
+		credentials = map[string]string{"email": employeeEmail, "password": password}
+		resp, res = createEmployee(t, employeeEmail, password, "support_agent")
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		user, _ = res["user"].(map[string]any)
+		employeeID, _ = user["ID"].(string)
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		employeeToken, _ = res["token"].(string)
+	})
+
+	t.Run("each login starts a session", func(t *testing.T) {
+		credentials := map[string]string{"email": customerEmail, "password": password}
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		laptopToken, _ = res["token"].(string)
+		laptopRefresh, _ = res["refresh_token"].(string)
+
+		resp, res = doJSON(t, "POST", "/users/login", "", credentials, "User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		phoneToken, _ = res["token"].(string)
+		phoneRefresh, _ = res["refresh_token"].(string)
+
+		list := sessions(t, "/users/me/sessions", laptopToken)
+		assert.Len(t, list, 2)
+		labels := map[string]bool{}
+		for _, session := range list {
+			labels[session["device_label"].(string)] = session["current"].(bool)
+			if session["device_label"] == "Safari on iOS" {
+				phoneSessionID, _ = session["id"].(string)
+			}
+		}
+		assert.Equal(t, map[string]bool{"Chrome on macOS": true, "Safari on iOS": false}, labels)
+		assert.NotEmpty(t, phoneSessionID)
+	})
+
+	t.Run("refresh keeps the session", func(t *testing.T) {
+		resp, res := doJSON(t, "POST", "/users/token/refresh", "", map[string]string{"refresh_token": phoneRefresh})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		phoneToken, _ = res["token"].(string)
+		phoneRefresh, _ = res["refresh_token"].(string)
+		assert.Len(t, sessions(t, "/users/me/sessions", phoneToken), 2)
+	})
+
+	t.Run("revoking a session ends its tokens", func(t *testing.T) {
+		resp, _ := doJSON(t, "DELETE", "/users/me/sessions/"+phoneSessionID, laptopToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", phoneToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp, _ = doJSON(t, "POST", "/users/token/refresh", "", map[string]string{"refresh_token": phoneRefresh})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", laptopToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, sessions(t, "/users/me/sessions", laptopToken), 1)
+
+		resp, _ = doJSON(t, "DELETE", "/users/me/sessions/"+phoneSessionID, laptopToken, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("customers cannot manage other users' sessions", func(t *testing.T) {
+		resp, _ := doJSON(t, "GET", "/users/"+employeeID+"/sessions", laptopToken, nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("employee lists and revokes a customer's session", func(t *testing.T) {
+		list := sessions(t, "/users/"+customerID+"/sessions", employeeToken)
+		assert.Len(t, list, 1)
+		laptopSessionID, _ := list[0]["id"].(string)
+
+		resp, _ := doJSON(t, "GET", "/users/"+employeeID+"/sessions", employeeToken, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = doJSON(t, "DELETE", "/users/"+customerID+"/sessions/"+laptopSessionID, employeeToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, _ = doJSON(t, "GET", "/users/me", laptopToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp, _ = doJSON(t, "POST", "/users/token/refresh", "", map[string]string{"refresh_token": laptopRefresh})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("logout ends the session", func(t *testing.T) {
+		assert.Len(t, sessions(t, "/users/me/sessions", employeeToken), 1)
+		resp, _ := doJSON(t, "POST", "/users/logout", employeeToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		credentials := map[string]string{"email": employeeEmail, "password": password}
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ := res["token"].(string)
+		assert.Len(t, sessions(t, "/users/me/sessions", token), 1)
+	})
+}
@@ -20,13 +20,7 @@ func TestSpecialEmployeeEndpoint(t *testing.T) {
 
 	// --- Employee user setup ---
 	t.Run("create employee", func(t *testing.T) {
-		payload := map[string]string{
-			"email":    employeeEmail,
-			"password": password,
-		}
-		body, _ := json.Marshal(payload)
-		resp, err := http.Post(baseURL+"/users/create-employee", "application/json", bytes.NewReader(body))
-		assert.NoError(t, err)
+		resp, _ := createEmployee(t, employeeEmail, password, "admin")
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 	})
 
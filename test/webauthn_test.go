@@ -0,0 +1,243 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const webauthnOrigin = "http://localhost:8080"
+
+// softAuthenticator is a minimal in-memory ES256 passkey used to drive the
+// WebAuthn ceremonies end to end.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	counter      uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &softAuthenticator{key: key, credentialID: id}
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte("localhost"))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    webauthnOrigin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) create(t *testing.T, challenge string) map[string]any {
+	coseKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	assert.NoError(t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestation, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(0x45, attested), // UP | UV | AT
+	})
+	assert.NoError(t, err)
+
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	return map[string]any{
+		"id":    id,
+		"rawId": id,
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData("webauthn.create", challenge)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestation),
+		},
+	}
+}
+
+func (a *softAuthenticator) get(t *testing.T, challenge string, userHandle []byte) map[string]any {
+	authData := a.authData(0x05, nil) // UP | UV
+	clientData := a.clientData("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	assert.NoError(t, err)
+
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	return map[string]any{
+		"id":    id,
+		"rawId": id,
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(userHandle),
+		},
+	}
+}
+
+func TestWebAuthnPasskeyLogin(t *testing.T) {
+	email := "passkey+" + time.Now().Format("150405") + "@test.com"
+	password := "passkeypassword"
+
+	var token string
+	var userHandle []byte
+	authenticator := newSoftAuthenticator(t)
+
+	// begin runs a begin endpoint and returns the session id and challenge.
+	begin := func(t *testing.T, path, bearer string) (string, string, map[string]any) {
+		resp, res := doJSON(t, "POST", path, bearer, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		sessionID, _ := res["session_id"].(string)
+		options, _ := res["options"].(map[string]any)
+		publicKey, _ := options["publicKey"].(map[string]any)
+		challenge, _ := publicKey["challenge"].(string)
+		assert.NotEmpty(t, sessionID)
+		assert.NotEmpty(t, challenge)
+		return sessionID, challenge, publicKey
+	}
+
+	t.Run("setup - register, verify email and login", func(t *testing.T) {
+		credentials := map[string]string{"email": email, "password": password}
+		resp, _ := doJSON(t, "POST", "/users/register", "", credentials)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp, err := http.Get(baseURL + "/users/verify-email?token=" + mailToken(t, email))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, res := doJSON(t, "POST", "/users/login", "", credentials)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		token, _ = res["token"].(string)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("registration requires authentication", func(t *testing.T) {
+		resp, _ := doJSON(t, "POST", "/users/webauthn/register/begin", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("register a passkey", func(t *testing.T) {
+		sessionID, challenge, publicKey := begin(t, "/users/webauthn/register/begin", token)
+		user, _ := publicKey["user"].(map[string]any)
+		encodedHandle, _ := user["id"].(string)
+		userHandle, _ = base64.RawURLEncoding.DecodeString(encodedHandle)
+		assert.Len(t, userHandle, 16)
+
+		resp, res := doJSON(t, "POST", "/users/webauthn/register/finish", token, map[string]any{
+			"session_id": sessionID,
+			"name":       "test key",
+			"credential": authenticator.create(t, challenge),
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "test key", res["name"])
+		assert.Nil(t, res["public_key"])
+	})
+
+	t.Run("registration session cannot be reused", func(t *testing.T) {
+		sessionID, challenge, _ := begin(t, "/users/webauthn/register/begin", token)
+		other := newSoftAuthenticator(t)
+		payload := map[string]any{"session_id": sessionID, "credential": other.create(t, challenge)}
+
+		resp, _ := doJSON(t, "POST", "/users/webauthn/register/finish", token, payload)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, _ = doJSON(t, "POST", "/users/webauthn/register/finish", token, payload)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("login with the passkey", func(t *testing.T) {
+		authenticator.counter++
+		sessionID, challenge, _ := begin(t, "/users/webauthn/login/begin", "")
+		resp, res := doJSON(t, "POST", "/users/webauthn/login/finish", "", map[string]any{
+			"session_id": sessionID,
+			"credential": authenticator.get(t, challenge, userHandle),
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, res["token"])
+		assert.NotEmpty(t, res["refresh_token"])
+
+		req, _ := http.NewRequest("GET", baseURL+"/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+res["token"].(string))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("login rejects an assertion for another challenge", func(t *testing.T) {
+		authenticator.counter++
+		sessionID, _, _ := begin(t, "/users/webauthn/login/begin", "")
+		_, otherChallenge, _ := begin(t, "/users/webauthn/login/begin", "")
+		resp, _ := doJSON(t, "POST", "/users/webauthn/login/finish", "", map[string]any{
+			"session_id": sessionID,
+			"credential": authenticator.get(t, otherChallenge, userHandle),
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("login rejects a malformed session id", func(t *testing.T) {
+		authenticator.counter++
+		_, challenge, _ := begin(t, "/users/webauthn/login/begin", "")
+		resp, _ := doJSON(t, "POST", "/users/webauthn/login/finish", "", map[string]any{
+			"session_id": "not-a-uuid",
+			"credential": authenticator.get(t, challenge, userHandle),
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("passkey logins respect the account lockout", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp, _ := doJSON(t, "POST", "/users/login", "", map[string]string{"email": email, "password": "wrong-password"})
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		authenticator.counter++
+		sessionID, challenge, _ := begin(t, "/users/webauthn/login/begin", "")
+		resp, _ := doJSON(t, "POST", "/users/webauthn/login/finish", "", map[string]any{
+			"session_id": sessionID,
+			"credential": authenticator.get(t, challenge, userHandle),
+		})
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("login rejects a sign count that did not increase", func(t *testing.T) {
+		authenticator.counter = 1
+		sessionID, challenge, _ := begin(t, "/users/webauthn/login/begin", "")
+		resp, _ := doJSON(t, "POST", "/users/webauthn/login/finish", "", map[string]any{
+			"session_id": sessionID,
+			"credential": authenticator.get(t, challenge, userHandle),
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
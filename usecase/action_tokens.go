@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"time"
+)
+
+// issueActionToken replaces the user's outstanding tokens for purpose with a
+// new one and returns it in plaintext, ready to be put in a link.
+func issueActionToken(repo repository.ActionTokenRepository, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	if err := repo.InvalidateForUser(userID, purpose); err != nil {
+		return "", err
+	}
+	return createActionToken(repo, userID, purpose, "", ttl)
+}
+
+// createActionToken adds a token without touching the user's other tokens.
+func createActionToken(repo repository.ActionTokenRepository, userID uuid.UUID, purpose, email string, ttl time.Duration) (string, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	token := models.ActionToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(raw),
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := repo.Create(&token); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
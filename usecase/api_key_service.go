@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix starts every API key so leaked keys are easy to spot, e.g.
+// by secret scanners.
+const apiKeyPrefix = "sk_"
+
+// apiKeyTouchInterval limits how often a busy key's last use is written.
+const apiKeyTouchInterval = time.Minute
+
+// Scopes an API key can be granted. Each one opens a few endpoints to API
+// key requests; everything else still needs a login.
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeLockoutsRead = "lockouts:read"
+	ScopeClientsRead  = "clients:read"
+)
+
+var APIKeyScopes = []string{ScopeProfileRead, ScopeLockoutsRead, ScopeClientsRead}
+
+var (
+	ErrAPIKeysNotAllowed  = errors.New("API keys are not available for this account type")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrInvalidAPIKeyScope = errors.New("unknown API key scope")
+	ErrInvalidAPIKeyTTL   = errors.New("API key lifetime is out of range")
+)
+
+type APIKeyConfig struct {
+	AllowedAccountTypes []string
+	MaxTTL              time.Duration
+}
+
+type APIKeyService struct {
+	repo  repository.APIKeyRepository
+	users repository.UserRepository
+	roles repository.RoleRepository
+	cfg   APIKeyConfig
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository, roles repository.RoleRepository, cfg APIKeyConfig) *APIKeyService {
+	return &APIKeyService{repo: repo, users: users, roles: roles, cfg: cfg}
+}
+
+// CreateKey returns the new key with its plaintext, which is not stored and
+// cannot be shown again.
+func (s *APIKeyService) CreateKey(userID uuid.UUID, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", err
+	}
+	if !slices.Contains(s.cfg.AllowedAccountTypes, user.AccountType) {
+		return nil, "", ErrAPIKeysNotAllowed
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(APIKeyScopes, scope) {
+			return nil, "", ErrInvalidAPIKeyScope
+		}
+	}
+	if ttl <= 0 || ttl > s.cfg.MaxTTL {
+		return nil, "", ErrInvalidAPIKeyTTL
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	raw := apiKeyPrefix + secret
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(apiKeyPrefix)+8],
+		KeyHash:   utils.HashToken(raw),
+		Scopes:    strings.Join(slices.Compact(slices.Sorted(slices.Values(scopes))), ","),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.repo.Create(&key); err != nil {
+		return nil, "", err
+	}
+	return &key, raw, nil
+}
+
+func (s *APIKeyService) ListKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	return s.repo.ListForUser(userID)
+}
+
+func (s *APIKeyService) RevokeKey(userID, id uuid.UUID) error {
+	revoked, err := s.repo.Revoke(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a key to its owner and records its use from
+// ip. It returns a nil key, and no error, for keys that are unknown,
+// revoked or expired, or whose owner was deleted or may no longer use API
+// keys.
+func (s *APIKeyService) AuthenticateAPIKey(raw, ip string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, nil, nil
+	}
+	now := time.Now()
+	key, err := s.repo.FindActiveByHash(utils.HashToken(raw), now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user models.User
+	if err := s.users.FindByID(key.UserID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if !slices.Contains(s.cfg.AllowedAccountTypes, user.AccountType) {
+		return nil, nil, nil
+	}
+	if user.Permissions, err = s.roles.PermissionsForUser(user.ID); err != nil {
+		return nil, nil, err
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval || key.LastUsedIP == nil || *key.LastUsedIP != ip {
+		if err := s.repo.TouchLastUsed(key.ID, now, ip); err != nil {
+			utils.Logger.Warn("failed to record API key use", zap.String("api_key_id", key.ID.String()), zap.Error(err))
+		}
+	}
+	return key, &user, nil
+}
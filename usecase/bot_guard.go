@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+)
+
+const spentChallengeCleanupInterval = time.Minute * 5
+
+// CaptchaVerifier checks a response token from a third-party CAPTCHA widget.
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, response, remoteIP string) (bool, error)
+}
+
+type BotGuardConfig struct {
+	// Window is the period attempts are counted over. A limit of zero
+	// disables that signal.
+	Window           time.Duration
+	IPLimit          int
+	EmailDomainLimit int
+	UserAgentLimit   int
+	// ExemptEmailDomains are never counted, e.g. the company's own domain
+	// or webmail providers too busy to have a useful limit.
+	ExemptEmailDomains []string
+	// Difficulty is the number of leading zero bits a proof of work needs.
+	Difficulty   int
+	ChallengeTTL time.Duration
+	// Secret signs challenges, so any instance sharing it can verify them.
+	Secret []byte
+}
+
+// BotGuard asks for proof that a client is not a bot once the attempts from
+// its IP address, with its user agent, or for its email domain pass their
+// limit. The domain and user agent are counted across all clients, which
+// catches bots that rotate through more addresses than per-IP rate limits
+// can. The proof is either a solved proof-of-work challenge or, when a
+// CaptchaVerifier is configured, a CAPTCHA response.
+//
+// Challenges are stateless: everything needed to check one travels in its
+// signed token. Solved challenges are stored until they expire so each can
+// be used only once, whichever instance it is sent to. Attempts are counted
+// in memory, per instance.
+type BotGuard struct {
+	cfg     BotGuardConfig
+	spent   repository.BotChallengeRepository
+	captcha CaptchaVerifier
+	exempt  map[string]bool
+
+	mu        sync.Mutex
+	counters  map[string]*attemptCounter
+	lastSweep time.Time
+}
+
+type attemptCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+type powClaims struct {
+	Nonce      string `json:"n"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"e"`
+}
+
+// NewBotGuard takes a nil captcha to only offer proof-of-work challenges.
+func NewBotGuard(cfg BotGuardConfig, spent repository.BotChallengeRepository, captcha CaptchaVerifier) *BotGuard {
+	exempt := make(map[string]bool, len(cfg.ExemptEmailDomains))
+	for _, domain := range cfg.ExemptEmailDomains {
+		exempt[strings.ToLower(domain)] = true
+	}
+	g := &BotGuard{
+		cfg:       cfg,
+		spent:     spent,
+		captcha:   captcha,
+		exempt:    exempt,
+		counters:  make(map[string]*attemptCounter),
+		lastSweep: time.Now(),
+	}
+	return g
+}
+
+// Assess counts an attempt and reports whether it must carry a proof.
+// Every signal is counted even once one has tripped, so a client cannot
+// keep the others cool by solving challenges.
+//
+// A user agent is trivial to change, so its limit only catches bots that
+// keep one, and because everyone on the same release of a popular browser
+// sends the same string, it must stay well above their normal traffic.
+func (g *BotGuard) Assess(ip, emailDomain, userAgent string) bool {
+	emailDomain = strings.ToLower(emailDomain)
+	now := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sweep(now)
+
+	tripped := g.count("ip:"+ip, g.cfg.IPLimit, now)
+	if emailDomain != "" && !g.exempt[emailDomain] {
+		tripped = g.count("domain:"+emailDomain, g.cfg.EmailDomainLimit, now) || tripped
+	}
+	return g.count("ua:"+userAgent, g.cfg.UserAgentLimit, now) || tripped
+}
+
+// NewChallenge issues a proof-of-work challenge. The client must find a
+// solution such that SHA-256(token + ":" + solution) starts with
+// difficulty zero bits.
+func (g *BotGuard) NewChallenge() (string, int, time.Time, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", 0, time.Time{}, err
+	}
+	expiresAt := time.Now().Add(g.cfg.ChallengeTTL)
+	payload, err := json.Marshal(powClaims{
+		Nonce:      base64.RawURLEncoding.EncodeToString(nonce),
+		Difficulty: g.cfg.Difficulty,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + g.sign(encoded), g.cfg.Difficulty, expiresAt, nil
+}
+
+// CaptchaEnabled reports whether clients may answer with a CAPTCHA instead
+// of a proof of work.
+func (g *BotGuard) CaptchaEnabled() bool {
+	return g.captcha != nil
+}
+
+// VerifySolution accepts either a solved challenge or a CAPTCHA response.
+// An error means the spent challenge could not be stored or the CAPTCHA
+// provider could not be asked.
+func (g *BotGuard) VerifySolution(ctx context.Context, challenge, solution, captchaResponse, ip string) (bool, error) {
+	if challenge != "" && solution != "" {
+		ok, err := g.verifyProofOfWork(challenge, solution)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	if captchaResponse != "" && g.captcha != nil {
+		return g.captcha.Verify(ctx, captchaResponse, ip)
+	}
+	return false, nil
+}
+
+func (g *BotGuard) verifyProofOfWork(token, solution string) (bool, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(g.sign(encoded))) {
+		return false, nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, nil
+	}
+	var claims powClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false, nil
+	}
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if time.Now().After(expiresAt) {
+		return false, nil
+	}
+	sum := sha256.Sum256([]byte(token + ":" + solution))
+	if leadingZeroBits(sum[:]) < claims.Difficulty {
+		return false, nil
+	}
+	return g.spent.Spend(&models.SpentBotChallenge{Nonce: claims.Nonce, ExpiresAt: expiresAt})
+}
+
+func (g *BotGuard) sign(encoded string) string {
+	mac := hmac.New(sha256.New, g.cfg.Secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// count must be called with g.mu held.
+func (g *BotGuard) count(key string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return false
+	}
+	counter, ok := g.counters[key]
+	if !ok || now.After(counter.resetAt) {
+		counter = &attemptCounter{resetAt: now.Add(g.cfg.Window)}
+		g.counters[key] = counter
+	}
+	counter.count++
+	return counter.count > limit
+}
+
+// sweep drops expired counters. It must be called with g.mu held.
+func (g *BotGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < g.cfg.Window {
+		return
+	}
+	for key, counter := range g.counters {
+		if now.After(counter.resetAt) {
+			delete(g.counters, key)
+		}
+	}
+	g.lastSweep = now
+}
+
+// CleanupSpent purges expired spent challenges every
+// spentChallengeCleanupInterval until ctx is done.
+func (g *BotGuard) CleanupSpent(ctx context.Context) {
+	ticker := time.NewTicker(spentChallengeCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := g.spent.PurgeExpired(time.Now()); err != nil {
+				utils.Logger.Warn("failed to purge spent bot challenges", zap.Error(err))
+			}
+		}
+	}
+}
+
+func leadingZeroBits(sum []byte) int {
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			return zeros + bits.LeadingZeros8(b)
+		}
+		zeros += 8
+	}
+	return zeros
+}
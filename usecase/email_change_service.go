@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	actionEmailChange = "email_change"
+	actionEmailRevert = "email_revert"
+)
+
+var (
+	ErrEmailUnchanged         = errors.New("new email is the current email")
+	ErrEmailTaken             = errors.New("email is already in use")
+	ErrInvalidEmailChangeLink = errors.New("invalid or expired email change link")
+)
+
+type EmailChangeConfig struct {
+	ConfirmTTL time.Duration
+	// RevertWindow is how long the old address can undo a change.
+	RevertWindow time.Duration
+	FrontendURL  string
+}
+
+// EmailChangeService moves an account to a new email address once the new
+// address is confirmed. The old address is told about every change and can
+// undo it for a while, which is what lets a user recover from someone who
+// took over their session and pointed the account at their own mailbox.
+type EmailChangeService struct {
+	tokens   repository.ActionTokenRepository
+	users    repository.UserRepository
+	sessions *TokenService
+	mailer   notification.Mailer
+	cfg      EmailChangeConfig
+}
+
+func NewEmailChangeService(tokens repository.ActionTokenRepository, users repository.UserRepository, sessions *TokenService, mailer notification.Mailer, cfg EmailChangeConfig) *EmailChangeService {
+	return &EmailChangeService{tokens: tokens, users: users, sessions: sessions, mailer: mailer, cfg: cfg}
+}
+
+// RequestChange emails a confirmation link to the new address and a revert
+// link to the current one. If the new address belongs to another account,
+// that address is told so instead and the call still succeeds, so the
+// endpoint cannot be used to find out which emails are registered.
+func (s *EmailChangeService) RequestChange(userID uuid.UUID, newEmail string) error {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return ErrEmailUnchanged
+	}
+
+	taken, err := s.emailTaken(newEmail, userID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		utils.Logger.Info("email change to a registered address", zap.String("user_id", userID.String()))
+		s.send(userID, notification.Message{
+			To:      newEmail,
+			Subject: "Email change request",
+			Body: "Someone asked to move another account to this email address, but it already " +
+				"belongs to an account. Nothing has been changed.\n",
+		})
+		return nil
+	}
+
+	if err := s.tokens.InvalidateForUser(userID, actionEmailChange); err != nil {
+		return err
+	}
+	confirm, err := createActionToken(s.tokens, userID, actionEmailChange, newEmail, s.cfg.ConfirmTTL)
+	if err != nil {
+		return err
+	}
+	// Earlier revert links stay valid: invalidating them would let whoever
+	// made this request cut the owner off from undoing a previous change.
+	revert, err := createActionToken(s.tokens, userID, actionEmailRevert, user.Email, s.cfg.RevertWindow)
+	if err != nil {
+		return err
+	}
+
+	s.send(userID, notification.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Open this link within %d hours to start using this address for your account:\n%s\n\n"+
+			"If you didn't ask for this, ignore this email.\n",
+			int(s.cfg.ConfirmTTL.Hours()), s.link("/confirm-email-change", confirm)),
+	})
+	s.send(userID, notification.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf("Someone asked to change the email address of your account to %s.\n\n"+
+			"If this wasn't you, open this link within %d days to keep this address and sign out every session:\n%s\n",
+			newEmail, int(s.cfg.RevertWindow.Hours()/24), s.link("/revert-email-change", revert)),
+	})
+
+	utils.Logger.Info("email change requested", zap.String("event", "email_change_requested"), zap.String("user_id", userID.String()))
+	return nil
+}
+
+// Confirm burns the confirmation token and moves the account to the new
+// address, which counts as verified. Links sent to the old address stop
+// working.
+func (s *EmailChangeService) Confirm(raw string) (*models.User, error) {
+	hash := utils.HashToken(raw)
+	token, err := s.tokens.FindValid(hash, actionEmailChange)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidEmailChangeLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Checked before the token is burned so the user can retry once the
+	// address is free.
+	taken, err := s.emailTaken(token.Email, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+	if _, err := s.tokens.Consume(hash, actionEmailChange); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidEmailChangeLink
+		}
+		return nil, err
+	}
+
+	user, err := s.setEmail(token.UserID, token.Email)
+	if err != nil {
+		return nil, err
+	}
+	utils.Logger.Info("email changed", zap.String("event", "email_changed"), zap.String("user_id", user.ID.String()))
+	return user, nil
+}
+
+// Revert burns the revert token and puts the account back on the address
+// it was sent to, whether or not the change was confirmed yet. Pending
+// changes are cancelled and every session is revoked, since a change the
+// owner didn't make means someone else had access.
+func (s *EmailChangeService) Revert(raw string) (*models.User, error) {
+	hash := utils.HashToken(raw)
+	token, err := s.tokens.FindValid(hash, actionEmailRevert)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidEmailChangeLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	taken, err := s.emailTaken(token.Email, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+	if _, err := s.tokens.Consume(hash, actionEmailRevert); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidEmailChangeLink
+		}
+		return nil, err
+	}
+
+	user, err := s.setEmail(token.UserID, token.Email)
+	if err != nil {
+		return nil, err
+	}
+	for _, purpose := range []string{actionEmailChange, actionEmailRevert} {
+		if err := s.tokens.InvalidateForUser(user.ID, purpose); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.sessions.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	utils.Logger.Warn("email change reverted", zap.String("event", "email_change_reverted"), zap.String("user_id", user.ID.String()))
+	return user, nil
+}
+
+// setEmail moves the user to an address they just proved control of. Links
+// that went to the previous address are burned. The uniq_active_email index
+// still guards against an account claiming the address in the meantime.
+func (s *EmailChangeService) setEmail(userID uuid.UUID, email string) (*models.User, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidEmailChangeLink
+		}
+		return nil, err
+	}
+	now := time.Now()
+	user.Email = email
+	user.EmailVerifiedAt = &now
+	if err := s.users.Save(&user); err != nil {
+		return nil, err
+	}
+	for _, purpose := range []string{actionPasswordReset, actionEmailVerification, actionMagicLink} {
+		if err := s.tokens.InvalidateForUser(user.ID, purpose); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+func (s *EmailChangeService) emailTaken(email string, userID uuid.UUID) (bool, error) {
+	other, err := s.users.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return other.ID != userID, nil
+}
+
+func (s *EmailChangeService) link(path, raw string) string {
+	return s.cfg.FrontendURL + path + "?token=" + url.QueryEscape(raw)
+}
+
+// send logs delivery failures instead of returning them; the request has
+// been recorded and the user can ask again.
+func (s *EmailChangeService) send(userID uuid.UUID, msg notification.Message) {
+	if err := s.mailer.Send(msg); err != nil {
+		utils.Logger.Error("failed to send email change message", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/url"
+	"time"
+)
+
+const actionEmailVerification = "email_verification"
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification link")
+
+type EmailVerificationConfig struct {
+	TTL            time.Duration
+	ResendInterval time.Duration
+	// VerifyURL is the link target; the token is appended as a query
+	// parameter.
+	VerifyURL string
+	// LoginURL is linked from the notice sent when someone tries to register
+	// an email that already has an account.
+	LoginURL string
+}
+
+type EmailVerificationService struct {
+	tokens repository.ActionTokenRepository
+	users  repository.UserRepository
+	mailer notification.Mailer
+	cfg    EmailVerificationConfig
+}
+
+func NewEmailVerificationService(tokens repository.ActionTokenRepository, users repository.UserRepository, mailer notification.Mailer, cfg EmailVerificationConfig) *EmailVerificationService {
+	return &EmailVerificationService{tokens: tokens, users: users, mailer: mailer, cfg: cfg}
+}
+
+// SendVerification emails the user a link that confirms their address. Any
+// earlier link stops working.
+func (s *EmailVerificationService) SendVerification(user *models.User) error {
+	raw, err := issueActionToken(s.tokens, user.ID, actionEmailVerification, s.cfg.TTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.cfg.VerifyURL + "?token=" + url.QueryEscape(raw)
+	return s.mailer.Send(notification.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Welcome! Please confirm this is your email address by opening this link:\n%s\n\n"+
+			"If you didn't create an account, ignore this email.\n", link),
+	})
+}
+
+// SendAccountExists tells the owner of an existing account that someone
+// tried to register with their email. Registration answers as if it had
+// succeeded, so this is how a real owner who forgot they signed up finds out.
+func (s *EmailVerificationService) SendAccountExists(user *models.User) error {
+	return s.mailer.Send(notification.Message{
+		To:      user.Email,
+		Subject: "You already have an account",
+		Body: fmt.Sprintf("Someone tried to create an account with this email address, but you already have one.\n\n"+
+			"If that was you, sign in here; you can reset your password from there if you've forgotten it:\n%s\n\n"+
+			"If it wasn't you, ignore this email. Nothing about your account has changed.\n", s.cfg.LoginURL),
+	})
+}
+
+// Resend sends a new verification link to the account with the given email
+// unless it is already verified or one was sent within the resend interval.
+// Like password resets it never reveals whether the account exists: the
+// checks and the email happen in the background, so every case answers
+// after the same work.
+func (s *EmailVerificationService) Resend(email string) error {
+	user, err := s.users.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	go s.resend(user)
+	return nil
+}
+
+func (s *EmailVerificationService) resend(user *models.User) {
+	if user.EmailVerifiedAt != nil {
+		return
+	}
+
+	latest, err := s.tokens.FindLatest(user.ID, actionEmailVerification)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.Logger.Error("failed to look up verification token", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < s.cfg.ResendInterval {
+		utils.Logger.Info("verification email throttled", zap.String("user_id", user.ID.String()))
+		return
+	}
+
+	if err := s.SendVerification(user); err != nil {
+		utils.Logger.Error("failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+// Verify burns the token and marks the user's email as verified.
+func (s *EmailVerificationService) Verify(raw string) (*models.User, error) {
+	token, err := s.tokens.Consume(utils.HashToken(raw), actionEmailVerification)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.users.FindByID(token.UserID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.users.Save(&user); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
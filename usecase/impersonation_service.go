@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrCannotImpersonate = errors.New("only customer accounts can be impersonated")
+
+type ImpersonationConfig struct {
+	TTL time.Duration
+}
+
+// ImpersonationService lets support staff act as a customer and keeps an
+// audit trail of everything they do while doing so.
+type ImpersonationService struct {
+	users  repository.UserRepository
+	audit  repository.AuditEventRepository
+	tokens *TokenService
+	cfg    ImpersonationConfig
+}
+
+func NewImpersonationService(users repository.UserRepository, audit repository.AuditEventRepository, tokens *TokenService, cfg ImpersonationConfig) *ImpersonationService {
+	return &ImpersonationService{users: users, audit: audit, tokens: tokens, cfg: cfg}
+}
+
+// Start issues a token that lets actorID act as the user. The start is
+// recorded before the token is handed out, so there is no impersonation
+// without an audit entry.
+func (s *ImpersonationService) Start(actorID uuid.UUID, actorAMR []string, userID uuid.UUID, reason, ip string) (string, time.Time, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", time.Time{}, ErrUserNotFound
+		}
+		return "", time.Time{}, err
+	}
+	if user.AccountType != models.AccountTypeCustomer {
+		return "", time.Time{}, ErrCannotImpersonate
+	}
+
+	if err := s.audit.Create(&models.AuditEvent{
+		Action:  models.AuditImpersonationStarted,
+		ActorID: actorID,
+		UserID:  userID,
+		Reason:  reason,
+		IP:      ip,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return s.tokens.IssueImpersonationToken(&user, actorID, actorAMR, s.cfg.TTL)
+}
+
+// RecordImpersonatedRequest adds a request made with an impersonation token
+// to the audit trail. The response has already been sent, so failures are
+// only logged.
+func (s *ImpersonationService) RecordImpersonatedRequest(actorID, userID uuid.UUID, jti, method, path, ip string, status int) {
+	err := s.audit.Create(&models.AuditEvent{
+		Action:  models.AuditImpersonatedRequest,
+		ActorID: actorID,
+		UserID:  userID,
+		TokenID: jti,
+		Method:  method,
+		Path:    path,
+		Status:  status,
+		IP:      ip,
+	})
+	if err != nil {
+		utils.Logger.Error("failed to audit impersonated request",
+			zap.String("actor_id", actorID.String()),
+			zap.String("user_id", userID.String()),
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+	}
+}
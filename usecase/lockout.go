@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy throttles password guessing against a single account. The
+// first BackoffAfter-1 failures are free; from then on each failure blocks
+// logins for BackoffBase, doubling every time, until Threshold failures lock
+// the account for LockoutDuration.
+type LockoutPolicy struct {
+	BackoffAfter    int
+	BackoffBase     time.Duration
+	Threshold       int
+	LockoutDuration time.Duration
+}
+
+// delay is how long logins are blocked after the given number of
+// consecutive failures.
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	switch {
+	case p.Threshold > 0 && failures >= p.Threshold:
+		return p.LockoutDuration
+	case p.BackoffAfter > 0 && failures >= p.BackoffAfter:
+		// Capped so the shift cannot overflow when there is no threshold.
+		return p.BackoffBase << min(failures-p.BackoffAfter, 16)
+	default:
+		return 0
+	}
+}
+
+// AccountLockedError is returned by UserService.Login while the account is
+// blocked by the lockout policy.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.Format(time.RFC3339))
+}
+
+// unknownEmailNamespace keeps the keys from unknownEmailKey apart from real
+// user IDs, which are random.
+var unknownEmailNamespace = uuid.MustParse("0f5c8c1e-7d4a-4f0b-9b8e-3c1d2a6e5f47")
+
+// unknownEmailKey stands in for the user ID when counting failed logins for
+// an email without an account, so guessing at unknown emails is throttled
+// the same way and the responses don't tell the two apart.
+func unknownEmailKey(email string) uuid.UUID {
+	return uuid.NewSHA1(unknownEmailNamespace, []byte(strings.ToLower(strings.TrimSpace(email))))
+}
+
+// LockoutState returns the user's failed login record, which is empty for
+// users without failures.
+func (s *UserService) LockoutState(userID uuid.UUID) (*models.LoginFailure, error) {
+	var user models.User
+	if err := s.repo.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	state, err := s.failures.Find(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.LoginFailure{UserID: userID}, nil
+	}
+	return state, err
+}
+
+// checkLockout refuses the attempt outright while the account is blocked, so
+// that not even a correct password is tested.
+func (s *UserService) checkLockout(userID uuid.UUID) (*models.LoginFailure, error) {
+	state, err := s.failures.Find(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.LockedUntil != nil && state.LockedUntil.After(time.Now()) {
+		utils.Logger.Warn("login attempt on locked account",
+			zap.String("event", "login_blocked"),
+			zap.String("user_id", userID.String()),
+			zap.Int("failed_attempts", state.FailedAttempts),
+			zap.Time("locked_until", *state.LockedUntil),
+		)
+		return state, &AccountLockedError{Until: *state.LockedUntil}
+	}
+	return state, nil
+}
+
+// VerifyCode runs verify, which checks a one-time code such as a TOTP or a
+// recovery code, under the lockout policy. While the account is blocked it
+// returns an *AccountLockedError without calling verify. ErrInvalidMFACode
+// and ErrInvalidRecoveryCode count as failed logins, and a valid code resets
+// the count like a correct password.
+func (s *UserService) VerifyCode(userID uuid.UUID, verify func() error) error {
+	state, err := s.checkLockout(userID)
+	if err != nil {
+		return err
+	}
+	if err := verify(); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) || errors.Is(err, ErrInvalidRecoveryCode) {
+			if recordErr := s.recordLoginFailure(userID); recordErr != nil {
+				return recordErr
+			}
+		}
+		return err
+	}
+	return s.resetLoginFailures(userID, state)
+}
+
+func (s *UserService) recordLoginFailure(userID uuid.UUID) error {
+	now := time.Now()
+	failures, err := s.failures.RecordFailure(userID, now)
+	if err != nil {
+		return err
+	}
+
+	fields := []zap.Field{
+		zap.String("event", "login_failure"),
+		zap.String("user_id", userID.String()),
+		zap.Int("failed_attempts", failures),
+	}
+	delay := s.lockout.delay(failures)
+	if delay > 0 {
+		until := now.Add(delay)
+		if err := s.failures.SetLockedUntil(userID, until); err != nil {
+			return err
+		}
+		fields = append(fields, zap.Time("locked_until", until))
+	}
+	utils.Logger.Warn("password login failed", fields...)
+
+	if failures == s.lockout.Threshold {
+		utils.Logger.Warn("account locked after repeated login failures",
+			zap.String("event", "account_locked"),
+			zap.String("user_id", userID.String()),
+			zap.Int("failed_attempts", failures),
+			zap.Duration("lockout", delay),
+		)
+	}
+	return nil
+}
+
+func (s *UserService) resetLoginFailures(userID uuid.UUID, state *models.LoginFailure) error {
+	if state == nil {
+		return nil
+	}
+	utils.Logger.Info("login succeeded after failures",
+		zap.String("event", "login_failures_reset"),
+		zap.String("user_id", userID.String()),
+		zap.Int("failed_attempts", state.FailedAttempts),
+	)
+	return s.failures.Reset(userID)
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net"
+	"net/url"
+	"time"
+)
+
+const actionLoginDisown = "login_disown"
+
+var ErrInvalidDisownLink = errors.New("invalid or expired link")
+
+type LoginAlertConfig struct {
+	// DisownTTL is how long the "this wasn't me" link in an alert works.
+	DisownTTL   time.Duration
+	FrontendURL string
+}
+
+// LoginAlertService tells users when their account is logged in to from a
+// device or network it has not been used from before, and lets them lock
+// the account down if it wasn't them.
+type LoginAlertService struct {
+	devices  repository.KnownDeviceRepository
+	tokens   repository.ActionTokenRepository
+	users    repository.UserRepository
+	sessions *TokenService
+	notifier notification.LoginNotifier
+	cfg      LoginAlertConfig
+}
+
+// NewLoginAlertService takes a nil notifier to record devices without
+// alerting anyone.
+func NewLoginAlertService(devices repository.KnownDeviceRepository, tokens repository.ActionTokenRepository, users repository.UserRepository, sessions *TokenService, notifier notification.LoginNotifier, cfg LoginAlertConfig) *LoginAlertService {
+	return &LoginAlertService{devices: devices, tokens: tokens, users: users, sessions: sessions, notifier: notifier, cfg: cfg}
+}
+
+// RecordLogin remembers the device a successful login came from and alerts
+// the user if it is new. The first device of an account is where it was
+// set up from, so it is recorded silently. Failures are only logged; they
+// never fail the login.
+func (s *LoginAlertService) RecordLogin(user *models.User, client ClientInfo) {
+	label := deviceLabel(client.UserAgent)
+	prefix := ipPrefix(client.IP)
+	fingerprint := utils.HashToken(label + "|" + prefix)
+	now := time.Now()
+
+	known, err := s.devices.Touch(user.ID, fingerprint, now)
+	if err == nil && !known {
+		var hadDevices, created bool
+		hadDevices, err = s.devices.HasAny(user.ID)
+		if err == nil {
+			created, err = s.devices.Create(&models.KnownDevice{
+				UserID:      user.ID,
+				Fingerprint: fingerprint,
+				DeviceLabel: label,
+				IPPrefix:    prefix,
+				LastSeenAt:  now,
+			})
+		}
+		// A concurrent login from the same device already sent the alert.
+		if err == nil && created && hadDevices {
+			err = s.alert(user, label, client.IP, now)
+		}
+	}
+	if err != nil {
+		utils.Logger.Error("failed to record login device", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+func (s *LoginAlertService) alert(user *models.User, label, ip string, at time.Time) error {
+	utils.Logger.Info("login from new device",
+		zap.String("event", "new_device_login"),
+		zap.String("user_id", user.ID.String()),
+		zap.String("device", label),
+		zap.String("ip", ip),
+	)
+	if s.notifier == nil {
+		return nil
+	}
+	// Earlier links stay valid, so a flood of logins cannot cut the owner
+	// off from reporting the first one.
+	raw, err := createActionToken(s.tokens, user.ID, actionLoginDisown, "", s.cfg.DisownTTL)
+	if err != nil {
+		return err
+	}
+	return s.notifier.NotifyNewLogin(notification.LoginAlert{
+		To:          user.Email,
+		DeviceLabel: label,
+		IP:          ip,
+		At:          at,
+		DisownURL:   s.cfg.FrontendURL + "/not-me?token=" + url.QueryEscape(raw),
+	})
+}
+
+// Disown handles a "this wasn't me" link: it burns the link, signs the user
+// out everywhere and refuses password logins until the password is reset.
+// Known devices are forgotten, so every login afterwards alerts again.
+func (s *LoginAlertService) Disown(raw string) (*models.User, error) {
+	token, err := s.tokens.Consume(utils.HashToken(raw), actionLoginDisown)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidDisownLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.users.FindByID(token.UserID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidDisownLink
+		}
+		return nil, err
+	}
+	user.PasswordResetRequired = true
+	if err := s.users.Save(&user); err != nil {
+		return nil, err
+	}
+	if err := s.sessions.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.devices.DeleteForUser(user.ID); err != nil {
+		return nil, err
+	}
+
+	utils.Logger.Warn("login disowned", zap.String("event", "login_disowned"), zap.String("user_id", user.ID.String()))
+	return &user, nil
+}
+
+// ipPrefix is the network an address belongs to, roughly one household or
+// office: the /24 for IPv4 and the /48 for IPv6.
+func ipPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/url"
+	"time"
+)
+
+const actionMagicLink = "magic_link"
+
+var ErrInvalidMagicLink = errors.New("invalid or expired login link")
+
+type MagicLinkService struct {
+	tokens   repository.ActionTokenRepository
+	users    repository.UserRepository
+	mailer   notification.Mailer
+	ttl      time.Duration
+	loginURL string
+}
+
+func NewMagicLinkService(tokens repository.ActionTokenRepository, users repository.UserRepository, mailer notification.Mailer, ttl time.Duration, frontendURL string) *MagicLinkService {
+	return &MagicLinkService{
+		tokens:   tokens,
+		users:    users,
+		mailer:   mailer,
+		ttl:      ttl,
+		loginURL: frontendURL + "/login/magic-link",
+	}
+}
+
+// Send emails a single-use login link to the account with the given email.
+// Unknown emails are ignored without an error so that the endpoint cannot be
+// used to find out who has an account, and the link is issued and mailed in
+// the background so that the response time does not tell either.
+func (s *MagicLinkService) Send(email string) error {
+	user, err := s.users.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	go s.sendLink(user)
+	return nil
+}
+
+func (s *MagicLinkService) sendLink(user *models.User) {
+	raw, err := issueActionToken(s.tokens, user.ID, actionMagicLink, s.ttl)
+	if err != nil {
+		utils.Logger.Error("failed to issue login link", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+
+	link := s.loginURL + "?token=" + url.QueryEscape(raw)
+	err = s.mailer.Send(notification.Message{
+		To:      user.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Open this link within %d minutes to log in:\n%s\n\n"+
+			"The link works once. If you didn't ask for it, ignore this email.\n",
+			int(s.ttl.Minutes()), link),
+	})
+	if err != nil {
+		utils.Logger.Error("failed to send login link", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+// Consume burns the login link and returns its user. Following the link
+// proves control of the mailbox, so an unverified email becomes verified.
+func (s *MagicLinkService) Consume(raw string) (*models.User, error) {
+	token, err := s.tokens.Consume(utils.HashToken(raw), actionMagicLink)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidMagicLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.users.FindByID(token.UserID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.users.Save(&user); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
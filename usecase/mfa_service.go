@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("no pending multi-factor enrolment")
+	ErrInvalidMFACode    = errors.New("invalid verification code")
+)
+
+// TOTPEnrolment is returned once, when the user starts enrolling.
+type TOTPEnrolment struct {
+	Secret string
+	URI    string
+}
+
+type MFAService struct {
+	repo   repository.MFARepository
+	users  repository.UserRepository
+	key    []byte
+	issuer string
+}
+
+func NewMFAService(repo repository.MFARepository, users repository.UserRepository, key []byte, issuer string) *MFAService {
+	return &MFAService{repo: repo, users: users, key: key, issuer: issuer}
+}
+
+// EnrollTOTP creates a new unconfirmed secret for the user, replacing any
+// earlier unconfirmed one.
+func (s *MFAService) EnrollTOTP(userID uuid.UUID) (*TOTPEnrolment, error) {
+	factor, err := s.repo.FindTOTP(userID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		factor = &models.TOTPFactor{UserID: userID}
+	case err != nil:
+		return nil, err
+	case factor.ConfirmedAt != nil:
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.key, secret)
+	if err != nil {
+		return nil, err
+	}
+	factor.SecretEncrypted = encrypted
+	factor.LastUsedStep = 0
+	if err := s.repo.SaveTOTP(factor); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrolment{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the pending factor once the user proves their
+// authenticator produces valid codes.
+func (s *MFAService) ConfirmTOTP(userID uuid.UUID, code string) error {
+	factor, err := s.repo.FindTOTP(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if factor.ConfirmedAt != nil {
+		return ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.check(factor, code)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	factor.ConfirmedAt = &now
+	factor.LastUsedStep = step
+	return s.repo.SaveTOTP(factor)
+}
+
+// IsEnabled reports whether the user has a confirmed second factor.
+func (s *MFAService) IsEnabled(userID uuid.UUID) (bool, error) {
+	factor, err := s.repo.FindTOTP(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return factor.ConfirmedAt != nil, nil
+}
+
+// VerifyTOTP checks a login code. Each time step is accepted only once.
+func (s *MFAService) VerifyTOTP(userID uuid.UUID, code string) error {
+	factor, err := s.repo.FindTOTP(userID)
+	if err != nil || factor.ConfirmedAt == nil {
+		return ErrInvalidMFACode
+	}
+
+	step, err := s.check(factor, code)
+	if err != nil {
+		return err
+	}
+	fresh, err := s.repo.MarkTOTPStepUsed(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) check(factor *models.TOTPFactor, code string) (int64, error) {
+	secret, err := utils.DecryptSecret(s.key, factor.SecretEncrypted)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return 0, ErrInvalidMFACode
+	}
+	return step, nil
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScopeUsersRead lets a service look up users by ID.
+const ScopeUsersRead = "users:read"
+
+var (
+	ErrClientNotFound     = errors.New("client not found")
+	ErrInvalidClient      = errors.New("invalid client credentials")
+	ErrInvalidScope       = errors.New("scope not allowed for this client")
+	ErrInvalidScopeFormat = errors.New("scopes must be non-empty and must not contain spaces or commas")
+)
+
+type OAuthClientConfig struct {
+	TokenTTL time.Duration
+	// StatusCacheTTL is how long a revoked client's tokens may keep working
+	// on other instances.
+	StatusCacheTTL time.Duration
+}
+
+type clientStatus struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// OAuthClientService manages machine clients and issues their tokens. Service
+// tokens cannot be revoked one by one; revoking the client ends all of them.
+type OAuthClientService struct {
+	repo repository.OAuthClientRepository
+	cfg  OAuthClientConfig
+
+	mu     sync.Mutex
+	status map[string]clientStatus
+}
+
+func NewOAuthClientService(repo repository.OAuthClientRepository, cfg OAuthClientConfig) *OAuthClientService {
+	return &OAuthClientService{repo: repo, cfg: cfg, status: make(map[string]clientStatus)}
+}
+
+// CreateClient registers a client and returns it with its secret, which is
+// not stored and cannot be shown again.
+func (s *OAuthClientService) CreateClient(name string, scopes []string, createdBy uuid.UUID) (*models.OAuthClient, string, error) {
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, " ,") {
+			return nil, "", ErrInvalidScopeFormat
+		}
+	}
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	client := models.OAuthClient{
+		Name:       name,
+		SecretHash: utils.HashToken(secret),
+		Scopes:     strings.Join(scopes, ","),
+		CreatedBy:  createdBy,
+	}
+	if err := s.repo.Create(&client); err != nil {
+		return nil, "", err
+	}
+	return &client, secret, nil
+}
+
+func (s *OAuthClientService) ListClients() ([]models.OAuthClient, error) {
+	return s.repo.List()
+}
+
+func (s *OAuthClientService) RevokeClient(id uuid.UUID) error {
+	revoked, err := s.repo.Revoke(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrClientNotFound
+	}
+	s.setStatus(id.String(), false)
+	return nil
+}
+
+// AuthenticateClient checks a registered client's credentials. It lets
+// registered clients call endpoints protected by
+// middleware.ClientAuthMiddleware.
+func (s *OAuthClientService) AuthenticateClient(id, secret string) (bool, error) {
+	_, err := s.authenticate(id, secret)
+	if errors.Is(err, ErrInvalidClient) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// IssueToken implements the client-credentials grant. With no requested
+// scopes the token gets every scope of the client.
+func (s *OAuthClientService) IssueToken(id, secret string, requested []string) (string, time.Time, []string, error) {
+	client, err := s.authenticate(id, secret)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	allowed := client.ScopeList()
+	scopes := allowed
+	if len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(allowed, scope) {
+				return "", time.Time{}, nil, ErrInvalidScope
+			}
+		}
+		scopes = requested
+	}
+
+	token, expiresAt, err := utils.GenerateServiceToken(client.ID.String(), scopes, s.cfg.TokenTTL)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	if err := s.repo.TouchLastUsed(client.ID, time.Now()); err != nil {
+		utils.Logger.Warn("failed to record client use", zap.String("client_id", client.ID.String()), zap.Error(err))
+	}
+	return token, expiresAt, scopes, nil
+}
+
+// IsClientActive reports whether a client's tokens are still accepted.
+func (s *OAuthClientService) IsClientActive(clientID string) (bool, error) {
+	s.mu.Lock()
+	status, ok := s.status[clientID]
+	s.mu.Unlock()
+	if ok && time.Since(status.checkedAt) < s.cfg.StatusCacheTTL {
+		return status.active, nil
+	}
+
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return false, nil
+	}
+	_, err = s.repo.FindActive(id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	active := err == nil
+	s.setStatus(clientID, active)
+	return active, nil
+}
+
+func (s *OAuthClientService) authenticate(id, secret string) (*models.OAuthClient, error) {
+	clientID, err := uuid.Parse(id)
+	if err != nil || secret == "" {
+		return nil, ErrInvalidClient
+	}
+	client, err := s.repo.FindActive(clientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(utils.HashToken(secret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+func (s *OAuthClientService) setStatus(clientID string, active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[clientID] = clientStatus{active: active, checkedAt: time.Now()}
+}
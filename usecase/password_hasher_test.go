@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testArgon2Params keeps the tests fast; production settings come from
+// ARGON2_*.
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params)
+
+	encoded, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`, encoded)
+
+	other, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.NotEqual(t, encoded, other, "salts must differ")
+
+	assert.NoError(t, hasher.Verify(encoded, "correct horse"))
+	assert.ErrorIs(t, hasher.Verify(encoded, "battery staple"), ErrPasswordMismatch)
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	assert.True(t, NewArgon2idHasher(stronger).NeedsRehash(encoded))
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+
+	encoded, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.NoError(t, hasher.Verify(encoded, "correct horse"))
+	assert.ErrorIs(t, hasher.Verify(encoded, "battery staple"), ErrPasswordMismatch)
+	assert.False(t, hasher.NeedsRehash(encoded))
+	assert.True(t, NewBcryptHasher(5).NeedsRehash(encoded))
+}
+
+func TestHashersVerifyEachOther(t *testing.T) {
+	argon := NewArgon2idHasher(testArgon2Params)
+	bcrypt := NewBcryptHasher(4)
+
+	argonHash, err := argon.Hash("correct horse")
+	assert.NoError(t, err)
+	bcryptHash, err := bcrypt.Hash("correct horse")
+	assert.NoError(t, err)
+
+	assert.NoError(t, bcrypt.Verify(argonHash, "correct horse"))
+	assert.NoError(t, argon.Verify(bcryptHash, "correct horse"))
+	assert.True(t, argon.NeedsRehash(bcryptHash))
+	assert.True(t, bcrypt.NeedsRehash(argonHash))
+
+	assert.ErrorIs(t, argon.Verify("plaintext", "plaintext"), ErrUnknownHashFormat)
+}
+
+func TestArgon2idRejectsMalformedHashes(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params)
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	for name, encoded := range map[string]string{
+		"no lanes":            "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"no iterations":       "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"too little memory":   "$argon2id$v=19$m=8,t=1,p=2$" + salt + "$" + key,
+		"too much memory":     "$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"too many iterations": "$argon2id$v=19$m=64,t=4294967295,p=1$" + salt + "$" + key,
+		"wrong version":       "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key,
+		"missing key":         "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"bad base64":          "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!",
+		"too few fields":      "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				assert.ErrorIs(t, hasher.Verify(encoded, "correct horse"), errMalformedArgon2Hash)
+			})
+			assert.True(t, hasher.NeedsRehash(encoded))
+		})
+	}
+}
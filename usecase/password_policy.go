@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy is the set of rules a new password must satisfy.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// MinCharClasses is how many of lower case, upper case, digits and
+	// symbols the password must mix.
+	MinCharClasses int
+	// MinStrength is the lowest acceptable passwordStrength score, 0 to 4.
+	MinStrength    int
+	RejectEmail    bool
+	RejectBreached bool
+}
+
+// PasswordViolation describes one broken rule in a form clients can map onto
+// their form fields.
+type PasswordViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a rejected password broke.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		codes = append(codes, violation.Code)
+	}
+	return "password does not meet the policy: " + strings.Join(codes, ", ")
+}
+
+// PasswordPolicies picks the policy for an account type and validates
+// passwords against it.
+type PasswordPolicies struct {
+	fallback      PasswordPolicy
+	byAccountType map[string]PasswordPolicy
+	breached      repository.BreachedPasswordRepository
+}
+
+// NewPasswordPolicies uses fallback for account types without their own
+// policy. breached may be nil, which turns the breached password check off.
+func NewPasswordPolicies(fallback PasswordPolicy, byAccountType map[string]PasswordPolicy, breached repository.BreachedPasswordRepository) *PasswordPolicies {
+	return &PasswordPolicies{fallback: fallback, byAccountType: byAccountType, breached: breached}
+}
+
+func (p *PasswordPolicies) policyFor(accountType string) PasswordPolicy {
+	if policy, ok := p.byAccountType[accountType]; ok {
+		return policy
+	}
+	return p.fallback
+}
+
+// Validate returns a *PasswordPolicyError if the password breaks any rule of
+// the account type's policy. Other errors come from the breached password
+// lookup.
+func (p *PasswordPolicies) Validate(password, email, accountType string) error {
+	policy := p.policyFor(accountType)
+	var violations []PasswordViolation
+	violate := func(code, format string, args ...any) {
+		violations = append(violations, PasswordViolation{Field: "password", Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		violate("too_short", "must be at least %d characters", policy.MinLength)
+	}
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		violate("too_long", "must be at most %d bytes", policy.MaxLength)
+	}
+	if classes := charClasses(password); classes < policy.MinCharClasses {
+		violate("too_few_character_classes", "must mix at least %d of lower case, upper case, digits and symbols", policy.MinCharClasses)
+	}
+	if passwordStrength(password) < policy.MinStrength {
+		violate("too_weak", "is too easy to guess")
+	}
+	if policy.RejectEmail && containsEmail(password, email) {
+		violate("contains_email", "must not contain your email address")
+	}
+	if policy.RejectBreached && p.breached != nil {
+		breached, err := p.isBreached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violate("breached", "has appeared in a data breach, choose another one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// isBreached looks the password up by the first five characters of its SHA-1
+// so that the full hash never has to leave this function.
+func (p *PasswordPolicies) isBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := p.breached.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(suffixes, hash[5:]), nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+func containsEmail(password, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(email)
+	if email == "" {
+		return false
+	}
+	if strings.Contains(password, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return len(local) >= 3 && strings.Contains(password, local)
+}
+
+// passwordStrength scores a password from 0 (trivial) to 4 (strong) by its
+// estimated entropy: the character pool it draws from times its length,
+// where characters that repeat or continue a sequence like "abc" or "321"
+// count for a quarter.
+func passwordStrength(password string) int {
+	pool := 0
+	var lower, upper, digit, symbol bool
+	effective := 0.0
+	var previous rune = -1
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+		if delta := r - previous; previous >= 0 && delta >= -1 && delta <= 1 {
+			effective += 0.25
+		} else {
+			effective++
+		}
+		previous = r
+	}
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	bits := effective * math.Log2(float64(pool))
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 80:
+		return 3
+	default:
+		return 4
+	}
+}
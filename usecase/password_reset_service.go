@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/url"
+	"time"
+)
+
+const actionPasswordReset = "password_reset"
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordResetService struct {
+	tokens   repository.ActionTokenRepository
+	users    repository.UserRepository
+	accounts *UserService
+	mailer   notification.Mailer
+	ttl      time.Duration
+	resetURL string
+}
+
+func NewPasswordResetService(tokens repository.ActionTokenRepository, users repository.UserRepository, accounts *UserService, mailer notification.Mailer, ttl time.Duration, frontendURL string) *PasswordResetService {
+	return &PasswordResetService{
+		tokens:   tokens,
+		users:    users,
+		accounts: accounts,
+		mailer:   mailer,
+		ttl:      ttl,
+		resetURL: frontendURL + "/reset-password",
+	}
+}
+
+// RequestReset emails a reset link to the account with the given email. It
+// succeeds silently when there is no such account so that callers cannot
+// probe which emails are registered. The link is issued and mailed in the
+// background, so a known email takes no longer to answer than an unknown
+// one. Any earlier link stops working.
+func (s *PasswordResetService) RequestReset(email string) error {
+	user, err := s.users.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	go s.sendResetLink(user)
+	return nil
+}
+
+func (s *PasswordResetService) sendResetLink(user *models.User) {
+	raw, err := issueActionToken(s.tokens, user.ID, actionPasswordReset, s.ttl)
+	if err != nil {
+		utils.Logger.Error("failed to issue password reset token", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+
+	link := s.resetURL + "?token=" + url.QueryEscape(raw)
+	err = s.mailer.Send(notification.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your account.\n\n"+
+			"Open this link within %d minutes to choose a new password:\n%s\n\n"+
+			"If this wasn't you, ignore this email. Your password has not been changed.\n",
+			int(s.ttl.Minutes()), link),
+	})
+	if err != nil {
+		utils.Logger.Error("failed to send password reset email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+}
+
+// Reset burns the reset token and sets the new password, which signs the
+// user out everywhere. A password rejected by the policy leaves the token
+// usable so the user can pick another one.
+func (s *PasswordResetService) Reset(raw, password string) error {
+	hash := utils.HashToken(raw)
+	token, err := s.tokens.FindValid(hash, actionPasswordReset)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.accounts.ValidatePassword(token.UserID, password, ""); err != nil {
+		return err
+	}
+
+	token, err = s.tokens.Consume(hash, actionPasswordReset)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	return s.accounts.ResetPassword(token.UserID, password)
+}
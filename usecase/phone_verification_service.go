@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/notification"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"gorm.io/gorm"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoPhoneNumber             = errors.New("add a phone number to your profile first")
+	ErrPhoneAlreadyVerified      = errors.New("phone number is already verified")
+	ErrInvalidPhoneCode          = errors.New("invalid or expired code")
+	ErrPhoneCodeAttemptsExceeded = errors.New("too many wrong codes, request a new one")
+)
+
+// PhoneCodeThrottledError means a code was sent too recently to send
+// another before Until.
+type PhoneCodeThrottledError struct {
+	Until time.Time
+}
+
+func (e *PhoneCodeThrottledError) Error() string {
+	return fmt.Sprintf("a code was sent recently, try again after %s", e.Until.Format(time.RFC3339))
+}
+
+type PhoneVerificationConfig struct {
+	CodeTTL        time.Duration
+	ResendInterval time.Duration
+	MaxAttempts    int
+}
+
+type PhoneVerificationService struct {
+	repo  repository.PhoneVerificationRepository
+	users repository.UserRepository
+	sms   notification.SMSSender
+	cfg   PhoneVerificationConfig
+}
+
+func NewPhoneVerificationService(repo repository.PhoneVerificationRepository, users repository.UserRepository, sms notification.SMSSender, cfg PhoneVerificationConfig) *PhoneVerificationService {
+	return &PhoneVerificationService{repo: repo, users: users, sms: sms, cfg: cfg}
+}
+
+// SendCode texts a six digit code to the user's current phone number. Any
+// earlier code stops working.
+func (s *PhoneVerificationService) SendCode(userID uuid.UUID) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.PhoneNumber == "" {
+		return ErrNoPhoneNumber
+	}
+	if user.PhoneVerifiedAt != nil {
+		return ErrPhoneAlreadyVerified
+	}
+
+	latest, err := s.repo.FindLatest(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil {
+		if until := latest.CreatedAt.Add(s.cfg.ResendInterval); time.Now().Before(until) {
+			return &PhoneCodeThrottledError{Until: until}
+		}
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return err
+	}
+	err = s.repo.Replace(&models.PhoneVerification{
+		UserID:      userID,
+		PhoneNumber: user.PhoneNumber,
+		CodeHash:    utils.HashToken(code),
+		ExpiresAt:   time.Now().Add(s.cfg.CodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return s.sms.Send(notification.SMS{
+		To:   user.PhoneNumber,
+		Body: fmt.Sprintf("Your Sort verification code is %s. It expires in %d minutes.", code, int(s.cfg.CodeTTL.Minutes())),
+	})
+}
+
+// Confirm marks the phone number verified if code is the latest one sent to
+// it. Each code allows MaxAttempts guesses, after which a new one must be
+// requested.
+func (s *PhoneVerificationService) Confirm(userID uuid.UUID, code string) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	verification, err := s.repo.FindLatest(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidPhoneCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A code sent to a number the user has since replaced proves nothing.
+	if verification.UsedAt != nil || time.Now().After(verification.ExpiresAt) || verification.PhoneNumber != user.PhoneNumber {
+		return nil, ErrInvalidPhoneCode
+	}
+
+	allowed, err := s.repo.ClaimAttempt(verification.ID, s.cfg.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrPhoneCodeAttemptsExceeded
+	}
+	code = strings.ReplaceAll(code, " ", "")
+	if subtle.ConstantTimeCompare([]byte(verification.CodeHash), []byte(utils.HashToken(code))) != 1 {
+		return nil, ErrInvalidPhoneCode
+	}
+	fresh, err := s.repo.MarkUsed(verification.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrInvalidPhoneCode
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	if err := s.users.Save(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *PhoneVerificationService) findUser(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func newPhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
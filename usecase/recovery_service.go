@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"gorm.io/gorm"
+	"strings"
+)
+
+const recoveryCodeCount = 10
+
+var ErrInvalidRecoveryCode = errors.New("invalid email or recovery code")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type RecoveryService struct {
+	repo     repository.RecoveryCodeRepository
+	users    repository.UserRepository
+	accounts *UserService
+}
+
+// NewRecoveryService applies the login lockout of accounts to recovery
+// codes.
+func NewRecoveryService(repo repository.RecoveryCodeRepository, users repository.UserRepository, accounts *UserService) *RecoveryService {
+	return &RecoveryService{repo: repo, users: users, accounts: accounts}
+}
+
+// GenerateCodes replaces the user's recovery codes with a fresh set and
+// returns the plaintext codes. They are not retrievable afterwards.
+func (s *RecoveryService) GenerateCodes(userID uuid.UUID) ([]string, error) {
+	plain := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		plain = append(plain, code)
+		records = append(records, models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalizeRecoveryCode(code))})
+	}
+
+	if err := s.repo.ReplaceForUser(userID, records); err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+func (s *RecoveryService) Remaining(userID uuid.UUID) (int64, error) {
+	return s.repo.CountUnused(userID)
+}
+
+// Redeem burns a recovery code belonging to the account with the given
+// email. Wrong codes count towards the login lockout, for unknown emails as
+// well, and while the account is blocked it returns an *AccountLockedError.
+// Codes are looked up by hash, so an attempt costs one query and one
+// comparison whether or not the email is registered.
+func (s *RecoveryService) Redeem(email, code string) (*models.User, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	known := err == nil
+	key := unknownEmailKey(email)
+	if known {
+		key = user.ID
+	}
+
+	err = s.accounts.VerifyCode(key, func() error {
+		hash := utils.HashToken(normalizeRecoveryCode(code))
+		var stored models.RecoveryCode
+		if known {
+			found, err := s.repo.FindUnusedByHash(user.ID, hash)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				stored = *found
+			}
+		}
+		if subtle.ConstantTimeCompare([]byte(stored.CodeHash), []byte(hash)) != 1 {
+			return ErrInvalidRecoveryCode
+		}
+		used, err := s.repo.MarkUsed(stored.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidRecoveryCode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newRecoveryCode returns 50 random bits as "xxxxx-xxxxx".
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode makes codes typed with different case, spacing or
+// dashes compare equal.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
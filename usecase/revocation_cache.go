@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// revocationCache keeps recent revocation lookups in process so that
+// AuthMiddleware does not hit Postgres on every request. Revocations made by
+// this instance are written through immediately; revocations made by other
+// instances become visible once the cached entry expires.
+type revocationCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	jtis  map[string]cachedJTI
+	users map[uuid.UUID]cachedCutoff
+	// sessions reuses cachedJTI since session lookups have the same shape.
+	sessions map[uuid.UUID]cachedJTI
+}
+
+type cachedJTI struct {
+	revoked bool
+	until   time.Time
+}
+
+type cachedCutoff struct {
+	before time.Time
+	until  time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:      ttl,
+		jtis:     make(map[string]cachedJTI),
+		users:    make(map[uuid.UUID]cachedCutoff),
+		sessions: make(map[uuid.UUID]cachedJTI),
+	}
+}
+
+func (c *revocationCache) jti(jti string) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.jtis[jti]
+	if !exists || time.Now().After(entry.until) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+// setJTI caches a lookup. Revoked tokens are remembered until they expire
+// since a revocation can never be undone.
+func (c *revocationCache) setJTI(jti string, revoked bool, tokenExpiresAt time.Time) {
+	until := time.Now().Add(c.ttl)
+	if revoked {
+		until = tokenExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jtis[jti] = cachedJTI{revoked: revoked, until: until}
+}
+
+func (c *revocationCache) cutoff(userID uuid.UUID) (before time.Time, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.users[userID]
+	if !exists || time.Now().After(entry.until) {
+		return time.Time{}, false
+	}
+	return entry.before, true
+}
+
+func (c *revocationCache) setCutoff(userID uuid.UUID, before time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[userID] = cachedCutoff{before: before, until: time.Now().Add(c.ttl)}
+}
+
+func (c *revocationCache) session(id uuid.UUID) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.sessions[id]
+	if !exists || time.Now().After(entry.until) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+// setSession caches a lookup. Revoked sessions are remembered until
+// revokedUntil, after which no access token of the session is still valid.
+func (c *revocationCache) setSession(id uuid.UUID, revoked bool, revokedUntil time.Time) {
+	until := time.Now().Add(c.ttl)
+	if revoked {
+		until = revokedUntil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[id] = cachedJTI{revoked: revoked, until: until}
+}
+
+func (c *revocationCache) cleanup() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for jti, entry := range c.jtis {
+		if now.After(entry.until) {
+			delete(c.jtis, jti)
+		}
+	}
+	for userID, entry := range c.users {
+		if now.After(entry.until) {
+			delete(c.users, userID)
+		}
+	}
+	for id, entry := range c.sessions {
+		if now.After(entry.until) {
+			delete(c.sessions, id)
+		}
+	}
+}
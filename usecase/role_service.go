@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrUnknownRole            = errors.New("unknown role")
+	ErrRoleNotAllowed         = errors.New("role cannot be assigned to this account type")
+	ErrCannotChangeOwnRoles   = errors.New("you cannot change your own roles")
+	ErrCannotGrantPermissions = errors.New("cannot grant permissions you do not have")
+)
+
+// RoleService lets staff see roles and change which ones users hold.
+type RoleService struct {
+	users  repository.UserRepository
+	roles  repository.RoleRepository
+	audit  repository.AuditEventRepository
+	tokens *TokenService
+}
+
+func NewRoleService(users repository.UserRepository, roles repository.RoleRepository, audit repository.AuditEventRepository, tokens *TokenService) *RoleService {
+	return &RoleService{users: users, roles: roles, audit: audit, tokens: tokens}
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	return s.roles.List()
+}
+
+// UserRoles returns ErrUserNotFound for unknown or deleted users.
+func (s *RoleService) UserRoles(userID uuid.UUID) ([]models.Role, error) {
+	if _, err := s.findUser(userID); err != nil {
+		return nil, err
+	}
+	return s.roles.ListForUser(userID)
+}
+
+// SetUserRoles makes names the user's only roles on behalf of actorID, who
+// holds actorPermissions. Actors cannot change their own roles or grant a
+// permission they lack. The user is signed out everywhere so their next
+// login carries the new permissions, and the change is audited.
+func (s *RoleService) SetUserRoles(actorID uuid.UUID, actorPermissions []string, userID uuid.UUID, names []string, ip string) ([]models.Role, error) {
+	if actorID == userID {
+		return nil, ErrCannotChangeOwnRoles
+	}
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	names = slices.Compact(slices.Sorted(slices.Values(names)))
+	roles, err := assignableRoles(s.roles, actorPermissions, user.AccountType, names)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := make([]uuid.UUID, len(roles))
+	for i, role := range roles {
+		roleIDs[i] = role.ID
+	}
+
+	if err := s.roles.ReplaceForUser(userID, roleIDs); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllForUser(userID); err != nil {
+		return nil, err
+	}
+	if err := s.audit.Create(&models.AuditEvent{
+		Action:  models.AuditRolesChanged,
+		ActorID: actorID,
+		UserID:  userID,
+		Reason:  strings.Join(names, ","),
+		IP:      ip,
+	}); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// assignableRoles looks up the roles named by names, which must be sorted
+// and free of duplicates, for an account of accountType. An actor holding
+// actorPermissions may only hand out permissions they hold themselves.
+func assignableRoles(repo repository.RoleRepository, actorPermissions []string, accountType string, names []string) ([]models.Role, error) {
+	roles, err := repo.FindByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(names) {
+		return nil, ErrUnknownRole
+	}
+	for _, role := range roles {
+		if role.AccountType != accountType {
+			return nil, ErrRoleNotAllowed
+		}
+		for _, permission := range role.PermissionNames() {
+			if !slices.Contains(actorPermissions, permission) {
+				return nil, ErrCannotGrantPermissions
+			}
+		}
+	}
+	return roles, nil
+}
+
+func (s *RoleService) findUser(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"gorm.io/gorm"
+)
+
+var ErrCannotManageSessions = errors.New("only customer sessions can be managed by employees")
+
+// SessionService lets users see and end their logins, and support staff do
+// the same for customers.
+type SessionService struct {
+	users  repository.UserRepository
+	audit  repository.AuditEventRepository
+	tokens *TokenService
+}
+
+func NewSessionService(users repository.UserRepository, audit repository.AuditEventRepository, tokens *TokenService) *SessionService {
+	return &SessionService{users: users, audit: audit, tokens: tokens}
+}
+
+func (s *SessionService) ListSessions(userID uuid.UUID) ([]models.Session, error) {
+	return s.tokens.ListSessions(userID)
+}
+
+func (s *SessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+	return s.tokens.RevokeSession(userID, sessionID)
+}
+
+// ListCustomerSessions lists a customer's sessions for an employee.
+func (s *SessionService) ListCustomerSessions(customerID uuid.UUID) ([]models.Session, error) {
+	if err := s.requireCustomer(customerID); err != nil {
+		return nil, err
+	}
+	return s.tokens.ListSessions(customerID)
+}
+
+// RevokeCustomerSession ends a customer's session on behalf of actorID and
+// records it in the audit trail.
+func (s *SessionService) RevokeCustomerSession(actorID, customerID, sessionID uuid.UUID, ip string) error {
+	if err := s.requireCustomer(customerID); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeSession(customerID, sessionID); err != nil {
+		return err
+	}
+	return s.audit.Create(&models.AuditEvent{
+		Action:  models.AuditSessionRevoked,
+		ActorID: actorID,
+		UserID:  customerID,
+		TokenID: sessionID.String(),
+		IP:      ip,
+	})
+}
+
+func (s *SessionService) requireCustomer(userID uuid.UUID) error {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.AccountType != models.AccountTypeCustomer {
+		return ErrCannotManageSessions
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrTokenAlreadyUsed    = errors.New("token has already been used")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+var revocationCleanupInterval = time.Minute * 5
+
+// TokenPair is what a client receives after authenticating or refreshing.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// ClientInfo describes the device a login or refresh came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type TokenConfig struct {
+	AccessTTL          time.Duration
+	RefreshTTL         time.Duration
+	RevocationCacheTTL time.Duration
+}
+
+type TokenService struct {
+	users       repository.UserRepository
+	refresh     repository.RefreshTokenRepository
+	revocations repository.TokenRevocationRepository
+	sessions    repository.SessionRepository
+	roles       repository.RoleRepository
+	clients     *OAuthClientService
+	cache       *revocationCache
+	cfg         TokenConfig
+}
+
+func NewTokenService(users repository.UserRepository, refresh repository.RefreshTokenRepository, revocations repository.TokenRevocationRepository, sessions repository.SessionRepository, roles repository.RoleRepository, clients *OAuthClientService, cfg TokenConfig) *TokenService {
+	s := &TokenService{
+		users:       users,
+		refresh:     refresh,
+		revocations: revocations,
+		sessions:    sessions,
+		roles:       roles,
+		clients:     clients,
+		cache:       newRevocationCache(cfg.RevocationCacheTTL),
+		cfg:         cfg,
+	}
+	go s.cleanupRevocations()
+	return s
+}
+
+// IssueTokens starts a new session, and with it a new refresh token family,
+// for the user. amr lists the methods the user authenticated with and is
+// carried over on refresh.
+func (s *TokenService) IssueTokens(user *models.User, amr []string, client ClientInfo) (*TokenPair, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		DeviceLabel: deviceLabel(client.UserAgent),
+		AMR:         strings.Join(amr, ","),
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(s.cfg.RefreshTTL),
+	}
+	if err := s.sessions.Create(&session); err != nil {
+		return nil, err
+	}
+	return s.issue(user, amr, now, session.ID, uuid.New())
+}
+
+// IssueReauthenticatedToken signs an access token for a user who just
+// proved who they are again, for routes behind
+// middleware.RequireRecentAuth. It belongs to the same session as the
+// caller's token; no refresh token is issued, so refreshing falls back to
+// the login's auth_time.
+func (s *TokenService) IssueReauthenticatedToken(user *models.User, amr []string, sessionID uuid.UUID) (string, time.Time, error) {
+	permissions, err := s.roles.PermissionsForUser(user.ID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return utils.GenerateToken(utils.AccessClaims{
+		UserID:        user.ID,
+		AccountType:   user.AccountType,
+		AMR:           amr,
+		Permissions:   permissions,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		SessionID:     sessionID,
+		AuthTime:      time.Now(),
+	}, s.cfg.AccessTTL)
+}
+
+// IssueImpersonationToken signs an access token that lets actorID act as
+// the user until ttl passes. No refresh token is issued, so the
+// impersonation cannot outlive it. amr describes how the actor logged in.
+func (s *TokenService) IssueImpersonationToken(user *models.User, actorID uuid.UUID, amr []string, ttl time.Duration) (string, time.Time, error) {
+	permissions, err := s.roles.PermissionsForUser(user.ID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return utils.GenerateToken(utils.AccessClaims{
+		UserID:        user.ID,
+		AccountType:   user.AccountType,
+		AMR:           amr,
+		Permissions:   permissions,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		ActorID:       actorID,
+	}, ttl)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// revoked in the process; presenting it again revokes its whole family and
+// ends the session.
+func (s *TokenService) Refresh(rawToken string, client ClientInfo) (*TokenPair, error) {
+	current, err := s.refresh.FindByHash(utils.HashToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		// Rotated tokens point at their replacement; tokens revoked by a
+		// logout do not, and presenting those is not a reuse signal.
+		if current.ReplacedBy == nil {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, s.handleReuse(current)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := s.users.FindByID(current.UserID, &user); err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextID := uuid.New()
+	rotated, err := s.refresh.MarkRotated(current.ID, nextID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated the token between our read and write.
+		return nil, s.handleReuse(current)
+	}
+
+	pair, err := s.issue(&user, strings.Split(current.AMR, ","), current.AuthTime, current.FamilyID, nextID)
+	if err != nil {
+		return nil, err
+	}
+	// Families issued before sessions were recorded have no session to touch.
+	if err := s.sessions.Touch(current.FamilyID, time.Now(), client.IP, client.UserAgent, pair.RefreshExpiresAt); err != nil {
+		utils.Logger.Warn("failed to record session activity", zap.String("session_id", current.FamilyID.String()), zap.Error(err))
+	}
+	return pair, nil
+}
+
+// Logout revokes the access token identified by jti and ends its session.
+// Tokens issued without a session have uuid.Nil as sessionID; for those the
+// refresh token, when given, identifies the family to revoke.
+func (s *TokenService) Logout(userID, sessionID uuid.UUID, jti string, expiresAt time.Time, rawRefreshToken string) error {
+	if _, err := s.revokeJTI(userID, jti, expiresAt); err != nil {
+		return err
+	}
+	if sessionID != uuid.Nil {
+		if err := s.RevokeSession(userID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+
+	if rawRefreshToken == "" {
+		return nil
+	}
+	refresh, err := s.refresh.FindByHash(utils.HashToken(rawRefreshToken))
+	if err != nil || refresh.UserID != userID {
+		return nil
+	}
+	return s.refresh.RevokeFamily(refresh.FamilyID)
+}
+
+// ConsumeOnce revokes a single-use token and reports ErrTokenAlreadyUsed when
+// it had been consumed before.
+func (s *TokenService) ConsumeOnce(userID uuid.UUID, jti string, expiresAt time.Time) error {
+	fresh, err := s.revokeJTI(userID, jti, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrTokenAlreadyUsed
+	}
+	return nil
+}
+
+// RevokeAllForUser invalidates every access and refresh token the user holds.
+func (s *TokenService) RevokeAllForUser(userID uuid.UUID) error {
+	// Truncated to the precision of iat so that tokens issued right after,
+	// e.g. to the user who just changed their password, are not caught.
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if err := s.revocations.RevokeUserTokens(userID, now); err != nil {
+		return err
+	}
+	s.cache.setCutoff(userID, now)
+	if err := s.sessions.RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+	return s.refresh.RevokeAllForUser(userID)
+}
+
+// ListSessions returns the user's active sessions, most recently seen first.
+func (s *TokenService) ListSessions(userID uuid.UUID) ([]models.Session, error) {
+	return s.sessions.ListActive(userID, time.Now())
+}
+
+// RevokeSession ends one of the user's sessions: its refresh tokens stop
+// working and AuthMiddleware rejects its access tokens.
+func (s *TokenService) RevokeSession(userID, sessionID uuid.UUID) error {
+	revoked, err := s.sessions.Revoke(userID, sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	s.cache.setSession(sessionID, true, time.Now().Add(s.cfg.AccessTTL))
+	return s.refresh.RevokeFamily(sessionID)
+}
+
+// IsSessionRevoked reports whether the session an access token belongs to
+// has been ended. Unknown sessions are not revoked.
+func (s *TokenService) IsSessionRevoked(sessionID uuid.UUID) (bool, error) {
+	if revoked, ok := s.cache.session(sessionID); ok {
+		return revoked, nil
+	}
+	session, err := s.sessions.FindByID(sessionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	revoked := err == nil && session.RevokedAt != nil
+	s.cache.setSession(sessionID, revoked, time.Now().Add(s.cfg.AccessTTL))
+	return revoked, nil
+}
+
+// IsRevoked reports whether an otherwise valid access token has been revoked,
+// either individually or by a user-wide cutoff.
+func (s *TokenService) IsRevoked(userID uuid.UUID, jti string, issuedAt, expiresAt time.Time) (bool, error) {
+	before, ok := s.cache.cutoff(userID)
+	if !ok {
+		var err error
+		before, err = s.revocations.UserRevokedBefore(userID)
+		if err != nil {
+			return false, err
+		}
+		s.cache.setCutoff(userID, before)
+	}
+	if issuedAt.Before(before) {
+		return true, nil
+	}
+
+	revoked, ok := s.cache.jti(jti)
+	if !ok {
+		var err error
+		revoked, err = s.revocations.IsTokenRevoked(jti)
+		if err != nil {
+			return false, err
+		}
+		s.cache.setJTI(jti, revoked, expiresAt)
+	}
+	return revoked, nil
+}
+
+// TokenIntrospection is what Introspect knows about a token. Token is nil
+// unless the token is well formed, signed by us and unexpired.
+type TokenIntrospection struct {
+	Active  bool
+	Revoked bool
+	Token   *utils.ParsedAccessToken
+}
+
+// Introspect reports whether an access token is currently accepted, for
+// services that cannot verify tokens themselves. Service tokens are revoked
+// with their client.
+func (s *TokenService) Introspect(raw string) (*TokenIntrospection, error) {
+	token, err := utils.ParseAccessToken(raw)
+	if err != nil {
+		return &TokenIntrospection{}, nil
+	}
+	if token.IsService() {
+		active, err := s.clients.IsClientActive(token.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		return &TokenIntrospection{Active: active, Revoked: !active, Token: token}, nil
+	}
+	revoked, err := s.IsRevoked(token.UserID, token.JTI, token.IssuedAt, token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked && token.SessionID != uuid.Nil {
+		revoked, err = s.IsSessionRevoked(token.SessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Signing the actor out everywhere also ends their impersonations.
+	if !revoked && token.IsImpersonation() {
+		revoked, err = s.IsRevoked(token.ActorID, token.JTI, token.IssuedAt, token.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &TokenIntrospection{Active: !revoked, Revoked: revoked, Token: token}, nil
+}
+
+func (s *TokenService) revokeJTI(userID uuid.UUID, jti string, expiresAt time.Time) (bool, error) {
+	fresh, err := s.revocations.RevokeToken(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	s.cache.setJTI(jti, true, expiresAt)
+	return fresh, nil
+}
+
+func (s *TokenService) cleanupRevocations() {
+	for {
+		time.Sleep(revocationCleanupInterval)
+		s.cache.cleanup()
+		if err := s.revocations.PurgeExpired(time.Now()); err != nil {
+			utils.Logger.Warn("failed to purge expired token revocations", zap.Error(err))
+		}
+	}
+}
+
+func (s *TokenService) handleReuse(token *models.RefreshToken) error {
+	utils.Logger.Warn("refresh token reuse detected, revoking family",
+		zap.String("user_id", token.UserID.String()),
+		zap.String("family_id", token.FamilyID.String()),
+	)
+	if err := s.RevokeSession(token.UserID, token.FamilyID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	if err := s.refresh.RevokeFamily(token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// issue resolves the user's permissions afresh, so refreshing picks up role
+// changes.
+func (s *TokenService) issue(user *models.User, amr []string, authTime time.Time, familyID, tokenID uuid.UUID) (*TokenPair, error) {
+	permissions, err := s.roles.PermissionsForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	access, accessExp, err := utils.GenerateToken(utils.AccessClaims{
+		UserID:        user.ID,
+		AccountType:   user.AccountType,
+		AMR:           amr,
+		Permissions:   permissions,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		SessionID:     familyID,
+		AuthTime:      authTime,
+	}, s.cfg.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	record := models.RefreshToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTTL),
+		AMR:       strings.Join(amr, ","),
+		AuthTime:  authTime,
+	}
+	if err := s.refresh.Create(&record); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     raw,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
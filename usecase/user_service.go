@@ -1,48 +1,203 @@
 package usecase
 
 import (
+	"errors"
 	"github.com/google/uuid"
 	"github.com/sandroJayas/user-service/domain/repository"
 	"github.com/sandroJayas/user-service/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"slices"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	// ErrPasswordResetRequired is returned by Login after a correct password,
+	// and by AcceptLogin, when the user has reported a login that wasn't
+	// them.
+	ErrPasswordResetRequired = errors.New("password reset required")
 )
 
 type UserService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	roles     repository.RoleRepository
+	tokens    *TokenService
+	failures  repository.LoginFailureRepository
+	lockout   LockoutPolicy
+	passwords *PasswordPolicies
+	hasher    PasswordHasher
+	alerts    *LoginAlertService
+	// dummyHash is verified against when logging in to an unknown email, so
+	// that takes as long as a wrong password for a real one.
+	dummyHash string
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo}
+// NewUserService fails if the hasher cannot produce the hash that logins
+// for unknown emails are compared against.
+func NewUserService(repo repository.UserRepository, roles repository.RoleRepository, tokens *TokenService, failures repository.LoginFailureRepository, lockout LockoutPolicy, passwords *PasswordPolicies, hasher PasswordHasher, alerts *LoginAlertService) (*UserService, error) {
+	dummyHash, err := hasher.Hash(uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+	return &UserService{repo: repo, roles: roles, tokens: tokens, failures: failures, lockout: lockout, passwords: passwords, hasher: hasher, alerts: alerts, dummyHash: dummyHash}, nil
 }
 
+// Register returns a *PasswordPolicyError if the password is not acceptable
+// for the user's account type, and ErrEmailTaken if the email is already
+// registered. The password is hashed either way so both outcomes take about
+// as long; user.Password holds the hash afterwards in both cases.
+// New customers get the customer role.
 func (s *UserService) Register(user *models.User) error {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(user.Password), 12)
+	return s.register(user, models.RoleCustomer)
+}
+
+// CreateEmployee registers an employee account holding the named roles on
+// behalf of an actor holding actorPermissions. Besides the errors of
+// Register it returns ErrUnknownRole, ErrRoleNotAllowed and
+// ErrCannotGrantPermissions, before anything is stored. Employees created
+// without roles have no permissions until roles are assigned.
+func (s *UserService) CreateEmployee(actorPermissions []string, user *models.User, roleNames []string) error {
+	user.AccountType = models.AccountTypeEmployee
+	roleNames = slices.Compact(slices.Sorted(slices.Values(roleNames)))
+	if _, err := assignableRoles(s.roles, actorPermissions, user.AccountType, roleNames); err != nil {
+		return err
+	}
+	return s.register(user, roleNames...)
+}
+
+// EnsureAdmin creates an employee with the admin role unless an account
+// already uses the email, so a new deployment has someone who can create
+// the other employees.
+func (s *UserService) EnsureAdmin(email, password string) error {
+	if _, err := s.repo.FindByEmail(email); !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	user := &models.User{Email: email, Password: password, AccountType: models.AccountTypeEmployee}
+	if err := s.register(user, models.RoleAdmin); err != nil && !errors.Is(err, ErrEmailTaken) {
+		return err
+	}
+	return nil
+}
+
+func (s *UserService) register(user *models.User, roleNames ...string) error {
+	if err := s.passwords.Validate(user.Password, user.Email, user.AccountType); err != nil {
+		return err
+	}
+	hashed, err := s.hasher.Hash(user.Password)
 	if err != nil {
 		return err
 	}
-	user.Password = string(hashed)
-	return s.repo.CreateUser(user)
+	user.Password = hashed
+
+	_, err = s.repo.FindByEmail(user.Email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.repo.CreateUser(user, roleNames...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *UserService) Login(email, password string) (*models.User, error) {
+// Login checks the password and applies the lockout policy. While the
+// account is locked it returns an *AccountLockedError without looking at the
+// password. A password hashed with outdated settings is rehashed while the
+// plaintext is at hand. Logins from a new device or network alert the user.
+// Unknown emails cost a password comparison and count towards the lockout
+// like wrong passwords, then return gorm.ErrRecordNotFound.
+func (s *UserService) Login(email, password string, client ClientInfo) (*models.User, error) {
 	user, err := s.repo.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		key := unknownEmailKey(email)
+		if _, err := s.checkLockout(key); err != nil {
+			return nil, err
+		}
+		_ = s.hasher.Verify(s.dummyHash, password)
+		if err := s.recordLoginFailure(key); err != nil {
+			return nil, err
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	state, err := s.checkLockout(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hasher.Verify(user.Password, password); err != nil {
+		if recordErr := s.recordLoginFailure(user.ID); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+	if err := s.resetLoginFailures(user.ID, state); err != nil {
 		return nil, err
 	}
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(user, password)
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	s.alerts.RecordLogin(user, client)
 	return user, nil
 }
 
+// AcceptLogin applies the checks that Login makes after the password to
+// users who proved who they are some other way, such as with a passkey or a
+// login link. It returns an *AccountLockedError while the account is
+// locked, and ErrPasswordResetRequired after a reported login, since
+// whoever made it may hold one of the other factors too. Otherwise it
+// records the device for login alerts.
+func (s *UserService) AcceptLogin(user *models.User, client ClientInfo) error {
+	if _, err := s.checkLockout(user.ID); err != nil {
+		return err
+	}
+	if user.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
+	s.alerts.RecordLogin(user, client)
+	return nil
+}
+
+// rehashPassword upgrades a stored hash. Failing to do so only means trying
+// again on the next login, so it never fails the login itself.
+func (s *UserService) rehashPassword(user *models.User, password string) {
+	hashed, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.repo.UpdatePassword(user.ID, hashed)
+	}
+	if err != nil {
+		utils.Logger.Error("password rehash failed", zap.String("user_id", user.ID.String()), zap.Error(err))
+		return
+	}
+	user.Password = hashed
+	utils.Logger.Info("password rehashed", zap.String("user_id", user.ID.String()))
+}
+
+// GetUserByID returns ErrUserNotFound for unknown or deleted users.
 func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
 	if err := s.repo.FindByID(id, &user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// UpdateUser replaces the profile fields. Changing the phone number clears
+// PhoneVerifiedAt, so the new number has to be verified again.
 func (s *UserService) UpdateUser(id uuid.UUID, data *models.User) (*models.User, error) {
 	var user models.User
 	if err := s.repo.FindByID(id, &user); err != nil {
@@ -56,6 +211,9 @@ func (s *UserService) UpdateUser(id uuid.UUID, data *models.User) (*models.User,
 	user.City = data.City
 	user.PostalCode = data.PostalCode
 	user.Country = data.Country
+	if data.PhoneNumber != user.PhoneNumber {
+		user.PhoneVerifiedAt = nil
+	}
 	user.PhoneNumber = data.PhoneNumber
 	user.PaymentMethodID = data.PaymentMethodID
 
@@ -66,5 +224,145 @@ func (s *UserService) UpdateUser(id uuid.UUID, data *models.User) (*models.User,
 }
 
 func (s *UserService) DeleteUser(id uuid.UUID) error {
-	return s.repo.SoftDelete(id)
+	if err := s.repo.SoftDelete(id); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllForUser(id)
+}
+
+// ValidatePassword checks a new password for an existing user against their
+// account type's policy. email is the address the account will have
+// afterwards; empty means it keeps the current one. Flows that burn a
+// single-use token call this first so a rejected password doesn't cost the
+// user their token.
+func (s *UserService) ValidatePassword(id uuid.UUID, password, email string) error {
+	var user models.User
+	if err := s.repo.FindByID(id, &user); err != nil {
+		return err
+	}
+	return s.validateFor(&user, password, email)
+}
+
+func (s *UserService) validateFor(user *models.User, password, email string) error {
+	if email == "" {
+		email = user.Email
+	}
+	return s.passwords.Validate(password, email, user.AccountType)
+}
+
+// RecoverAccount sets a new password for a user who proved ownership with a
+// recovery code. Every existing session is revoked since the old
+// credentials may be in someone else's hands, and any login lockout is
+// lifted. A new email goes through EmailChangeService like any other change.
+func (s *UserService) RecoverAccount(id uuid.UUID, password string) (*models.User, error) {
+	var user models.User
+	if err := s.repo.FindByID(id, &user); err != nil {
+		return nil, err
+	}
+	if err := s.validateFor(&user, password, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.setPassword(&user, password); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Save(&user); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllForUser(id); err != nil {
+		return nil, err
+	}
+	if err := s.failures.Reset(id); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword replaces the password of a signed-in user who knows the
+// current one. Wrong guesses count towards the login lockout, so a stolen
+// access token cannot be used to brute force the password. Every token
+// issued before the change stops working, including the caller's.
+func (s *UserService) ChangePassword(id uuid.UUID, current, password string) (*models.User, error) {
+	var user models.User
+	if err := s.repo.FindByID(id, &user); err != nil {
+		return nil, err
+	}
+	state, err := s.checkLockout(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hasher.Verify(user.Password, current); err != nil {
+		if !errors.Is(err, ErrPasswordMismatch) {
+			return nil, err
+		}
+		if recordErr := s.recordLoginFailure(id); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, ErrIncorrectPassword
+	}
+	if err := s.validateFor(&user, password, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.setPassword(&user, password); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(&user); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllForUser(id); err != nil {
+		return nil, err
+	}
+	if err := s.resetLoginFailures(id, state); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Reauthenticate checks the password of a signed-in user who is about to do
+// something sensitive. Like ChangePassword, wrong guesses count towards the
+// login lockout.
+func (s *UserService) Reauthenticate(id uuid.UUID, password string) (*models.User, error) {
+	var user models.User
+	if err := s.repo.FindByID(id, &user); err != nil {
+		return nil, err
+	}
+	state, err := s.checkLockout(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.hasher.Verify(user.Password, password); err != nil {
+		if !errors.Is(err, ErrPasswordMismatch) {
+			return nil, err
+		}
+		if recordErr := s.recordLoginFailure(id); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, ErrIncorrectPassword
+	}
+	if err := s.resetLoginFailures(id, state); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	return &user, nil
+}
+
+func (s *UserService) setPassword(user *models.User, password string) error {
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hashed
+	user.PasswordResetRequired = false
+	return nil
+}
+
+// ResetPassword sets a new password for a user who proved control of their
+// email address and revokes every existing session.
+func (s *UserService) ResetPassword(id uuid.UUID, password string) error {
+	_, err := s.RecoverAccount(id, password)
+	return err
 }
@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/sandroJayas/user-service/domain/repository"
+	"github.com/sandroJayas/user-service/models"
+	"github.com/sandroJayas/user-service/utils"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+var (
+	ErrWebAuthnSessionNotFound = errors.New("webauthn session not found or expired")
+	ErrWebAuthnFailed          = errors.New("webauthn verification failed")
+	ErrWebAuthnCloneDetected   = errors.New("authenticator sign count went backwards")
+)
+
+var webauthnSessionCleanupInterval = time.Minute * 5
+
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	SessionTTL    time.Duration
+}
+
+type WebAuthnService struct {
+	wa         *webauthn.WebAuthn
+	repo       repository.WebAuthnRepository
+	users      repository.UserRepository
+	sessionTTL time.Duration
+}
+
+func NewWebAuthnService(repo repository.WebAuthnRepository, users repository.UserRepository, cfg WebAuthnConfig) (*WebAuthnService, error) {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &WebAuthnService{wa: wa, repo: repo, users: users, sessionTTL: cfg.SessionTTL}
+	go s.cleanupSessions()
+	return s, nil
+}
+
+// BeginRegistration starts registering a new discoverable credential for the
+// user. The returned options are passed to navigator.credentials.create.
+func (s *WebAuthnService) BeginRegistration(userID uuid.UUID) (*protocol.CredentialCreation, uuid.UUID, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := s.wa.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(exclusions),
+	)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := s.storeSession(&userID, ceremonyRegistration, session)
+	return options, sessionID, err
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the
+// new credential.
+func (s *WebAuthnService) FinishRegistration(userID, sessionID uuid.UUID, name string, response []byte) (*models.WebAuthnCredential, error) {
+	session, err := s.takeSession(sessionID, ceremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID == nil || *session.UserID != userID {
+		return nil, ErrWebAuthnSessionNotFound
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.wa.CreateCredential(user, session.data, parsed)
+	if err != nil {
+		utils.Logger.Warn("webauthn registration rejected", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, ErrWebAuthnFailed
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+	record := models.WebAuthnCredential{
+		UserID:          userID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+	}
+	if err := s.repo.CreateCredential(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// BeginLogin starts a usernameless login: the authenticator picks the
+// credential and reports which user it belongs to.
+func (s *WebAuthnService) BeginLogin() (*protocol.CredentialAssertion, uuid.UUID, error) {
+	options, session, err := s.wa.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := s.storeSession(nil, ceremonyLogin, session)
+	return options, sessionID, err
+}
+
+// FinishLogin verifies the assertion, updates the credential's sign count and
+// returns the user it belongs to.
+func (s *WebAuthnService) FinishLogin(sessionID uuid.UUID, response []byte) (*models.User, error) {
+	session, err := s.takeSession(sessionID, ceremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+
+	var owner *webauthnUser
+	credential, err := s.wa.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		owner, err = s.loadUser(userID)
+		return owner, err
+	}, session.data, parsed)
+	if err != nil {
+		utils.Logger.Warn("webauthn login rejected", zap.Error(err))
+		return nil, ErrWebAuthnFailed
+	}
+
+	record := owner.find(credential.ID)
+	if credential.Authenticator.CloneWarning {
+		utils.Logger.Warn("webauthn sign count regression, possible cloned authenticator",
+			zap.String("user_id", owner.user.ID.String()),
+			zap.String("credential", record.ID.String()),
+		)
+		return nil, ErrWebAuthnCloneDetected
+	}
+
+	now := time.Now()
+	record.SignCount = int64(credential.Authenticator.SignCount)
+	record.UserVerified = credential.Flags.UserVerified
+	record.BackupState = credential.Flags.BackupState
+	record.LastUsedAt = &now
+	if err := s.repo.SaveCredential(record); err != nil {
+		return nil, err
+	}
+	return owner.user, nil
+}
+
+type webauthnSession struct {
+	UserID *uuid.UUID
+	data   webauthn.SessionData
+}
+
+func (s *WebAuthnService) storeSession(userID *uuid.UUID, ceremony string, data *webauthn.SessionData) (uuid.UUID, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	session := models.WebAuthnSession{
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Data:      encoded,
+		ExpiresAt: time.Now().Add(s.sessionTTL),
+	}
+	if err := s.repo.CreateSession(&session); err != nil {
+		return uuid.Nil, err
+	}
+	return session.ID, nil
+}
+
+func (s *WebAuthnService) takeSession(id uuid.UUID, ceremony string) (*webauthnSession, error) {
+	stored, err := s.repo.TakeSession(id, ceremony)
+	if err != nil {
+		return nil, ErrWebAuthnSessionNotFound
+	}
+	session := &webauthnSession{UserID: stored.UserID}
+	if err := json.Unmarshal(stored.Data, &session.data); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *WebAuthnService) loadUser(userID uuid.UUID) (*webauthnUser, error) {
+	var user models.User
+	if err := s.users.FindByID(userID, &user); err != nil {
+		return nil, err
+	}
+	credentials, err := s.repo.FindCredentialsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthnUser{user: &user, credentials: credentials}, nil
+}
+
+func (s *WebAuthnService) cleanupSessions() {
+	for {
+		time.Sleep(webauthnSessionCleanupInterval)
+		if err := s.repo.PurgeExpiredSessions(); err != nil {
+			utils.Logger.Warn("failed to purge expired webauthn sessions", zap.Error(err))
+		}
+	}
+}
+
+// webauthnUser adapts a user and their stored credentials to webauthn.User.
+// The user handle is the raw 16 byte user ID.
+type webauthnUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	if name := strings.TrimSpace(u.user.FirstName + " " + u.user.LastName); name != "" {
+		return name
+	}
+	return u.user.Email
+}
+
+func (u *webauthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, stored := range u.credentials {
+		var transports []protocol.AuthenticatorTransport
+		if stored.Transports != "" {
+			for _, transport := range strings.Split(stored.Transports, ",") {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              stored.CredentialID,
+			PublicKey:       stored.PublicKey,
+			AttestationType: stored.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				UserVerified:   stored.UserVerified,
+				BackupEligible: stored.BackupEligible,
+				BackupState:    stored.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    stored.AAGUID,
+				SignCount: uint32(stored.SignCount),
+			},
+		})
+	}
+	return credentials
+}
+
+func (u *webauthnUser) find(credentialID []byte) *models.WebAuthnCredential {
+	for i := range u.credentials {
+		if bytes.Equal(u.credentials[i].CredentialID, credentialID) {
+			return &u.credentials[i]
+		}
+	}
+	return nil
+}
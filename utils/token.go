@@ -1,21 +1,305 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
-	"os"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID uuid.UUID, accountType string) (string, error) {
-	var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// token_use values. AuthMiddleware only accepts access tokens; every other
+// use is a short-lived token that is only good for one specific endpoint.
+const (
+	TokenUseAccess   = "access"
+	TokenUseMFA      = "mfa"
+	TokenUseRecovery = "recovery"
+)
+
+// Authentication method references (RFC 8176) recorded in the amr claim.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	// AMREmailLink is not registered in RFC 8176; it marks logins through a
+	// link sent to the user's email address.
+	AMREmailLink = "email"
+	// AMRAPIKey is not registered in RFC 8176 either; it marks requests
+	// authenticated with a personal API key rather than a login.
+	AMRAPIKey = "api_key"
+)
+
+var (
+	ErrInvalidPurposeToken = errors.New("invalid or expired token")
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+)
+
+// AccessClaims describes the user an access token is issued to.
+type AccessClaims struct {
+	UserID      uuid.UUID
+	AccountType string
+	AMR         []string
+	// Permissions are those granted by the user's roles when the token was
+	// issued, carried in the permissions claim.
+	Permissions []string
+	// EmailVerified mirrors whether the user had confirmed their email
+	// address when the token was issued.
+	EmailVerified bool
+	// ActorID is the employee acting as the user, carried in the RFC 8693
+	// act claim of impersonation tokens. It is uuid.Nil otherwise.
+	ActorID uuid.UUID
+	// SessionID is the login session the token belongs to, carried in the
+	// sid claim. It is uuid.Nil for tokens issued outside a session.
+	SessionID uuid.UUID
+	// AuthTime is when the user last proved who they are, carried in the
+	// OIDC auth_time claim. Refreshing keeps it; re-authenticating moves it
+	// forward. Zero for tokens that were not issued after an
+	// authentication, such as impersonation tokens.
+	AuthTime time.Time
+}
+
+// IsImpersonation reports whether someone other than the user holds the
+// token.
+func (c AccessClaims) IsImpersonation() bool {
+	return c.ActorID != uuid.Nil
+}
+
+// GenerateToken signs an access token for the user with the current key from
+// Keys that expires after ttl. Every token carries a unique jti so it can be
+// revoked on its own, and a millisecond precision iat so user-wide
+// revocation cutoffs are exact.
+func GenerateToken(subject AccessClaims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"jti":            uuid.NewString(),
+		"token_use":      TokenUseAccess,
+		"user_id":        subject.UserID.String(),
+		"account_type":   subject.AccountType,
+		"amr":            subject.AMR,
+		"email_verified": subject.EmailVerified,
+		"iat":            float64(now.UnixMilli()) / 1000,
+		"exp":            expiresAt.Unix(),
+	}
+	if subject.IsImpersonation() {
+		claims["act"] = map[string]string{"sub": subject.ActorID.String()}
+	}
+	if subject.SessionID != uuid.Nil {
+		claims["sid"] = subject.SessionID.String()
+	}
+	if !subject.AuthTime.IsZero() {
+		claims["auth_time"] = subject.AuthTime.Unix()
+	}
+	if len(subject.Permissions) > 0 {
+		claims["permissions"] = subject.Permissions
+	}
+
+	signed, err := Keys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+// GenerateServiceToken signs an access token for a machine client from the
+// client-credentials grant. It carries client_id and a space separated scope
+// instead of user claims.
+func GenerateServiceToken(clientID string, scopes []string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
 	claims := jwt.MapClaims{
-		"user_id":      userID.String(),
-		"account_type": accountType,
-		"exp":          time.Now().Add(72 * time.Hour).Unix(),
+		"jti":       uuid.NewString(),
+		"token_use": TokenUseAccess,
+		"client_id": clientID,
+		"scope":     strings.Join(scopes, " "),
+		"iat":       float64(now.UnixMilli()) / 1000,
+		"exp":       expiresAt.Unix(),
+	}
+
+	signed, err := Keys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+// ParsedAccessToken is a verified access token. Tokens from
+// GenerateServiceToken have a ClientID and Scopes and no user claims.
+type ParsedAccessToken struct {
+	AccessClaims
+	ClientID  string
+	Scopes    []string
+	JTI       string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func (t *ParsedAccessToken) IsService() bool {
+	return t.ClientID != ""
+}
+
+// ParseAccessToken verifies the signature, expiry and shape of an access
+// token from GenerateToken. It does not check revocation. Errors wrap
+// ErrInvalidAccessToken.
+func ParseAccessToken(tokenStr string) (*ParsedAccessToken, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, Keys.Keyfunc, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, err)
+	}
+	if !token.Valid || claims["token_use"] != TokenUseAccess {
+		return nil, ErrInvalidAccessToken
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, fmt.Errorf("%w: jti claim is missing or invalid", ErrInvalidAccessToken)
+	}
+	// iat is read raw because jwt truncates NumericDates to whole seconds
+	// and revocation cutoffs need the millisecond precision we issue.
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: iat claim is missing or invalid", ErrInvalidAccessToken)
+	}
+	expiresAt, _ := claims.GetExpirationTime()
+	parsed := &ParsedAccessToken{
+		JTI:       jti,
+		IssuedAt:  time.UnixMilli(int64(math.Round(iat * 1000))),
+		ExpiresAt: expiresAt.Time,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	if clientID, _ := claims["client_id"].(string); clientID != "" {
+		scope, _ := claims["scope"].(string)
+		parsed.ClientID = clientID
+		parsed.Scopes = strings.Fields(scope)
+		return parsed, nil
+	}
+
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user_id claim is missing or invalid", ErrInvalidAccessToken)
+	}
+	accountType, _ := claims["account_type"].(string)
+	parsed.AccessClaims = AccessClaims{
+		UserID:        userID,
+		AccountType:   accountType,
+		AMR:           stringClaims(claims["amr"]),
+		Permissions:   stringClaims(claims["permissions"]),
+		EmailVerified: claims["email_verified"] == true,
+	}
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		actorIDStr, _ := act["sub"].(string)
+		actorID, err := uuid.Parse(actorIDStr)
+		if err != nil || actorID == uuid.Nil {
+			return nil, fmt.Errorf("%w: act claim is invalid", ErrInvalidAccessToken)
+		}
+		parsed.ActorID = actorID
+	}
+	if sid, ok := claims["sid"].(string); ok {
+		sessionID, err := uuid.Parse(sid)
+		if err != nil || sessionID == uuid.Nil {
+			return nil, fmt.Errorf("%w: sid claim is invalid", ErrInvalidAccessToken)
+		}
+		parsed.SessionID = sessionID
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		parsed.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	return parsed, nil
+}
+
+func stringClaims(raw any) []string {
+	items, _ := raw.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// PurposeClaims identifies the user and token behind a purpose token.
+type PurposeClaims struct {
+	UserID    uuid.UUID
+	JTI       string
+	ExpiresAt time.Time
+	// AMR lists the methods the user already authenticated with, for MFA
+	// challenges.
+	AMR []string
+}
+
+// GeneratePurposeToken signs a short-lived token that only proves the user
+// completed an earlier step of a flow, e.g. the recovery code step of an
+// account recovery.
+func GeneratePurposeToken(userID uuid.UUID, use string, ttl time.Duration) (string, time.Time, error) {
+	return signPurposeToken(userID, use, ttl, nil)
+}
+
+// GenerateMFAChallenge signs the purpose token handed out when the first
+// factor succeeded but a second one is still required. It remembers how the
+// first step was done so the final token's amr is accurate.
+func GenerateMFAChallenge(userID uuid.UUID, amr []string, ttl time.Duration) (string, time.Time, error) {
+	return signPurposeToken(userID, TokenUseMFA, ttl, amr)
+}
+
+func signPurposeToken(userID uuid.UUID, use string, ttl time.Duration, amr []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"jti":       uuid.NewString(),
+		"token_use": use,
+		"user_id":   userID.String(),
+		"iat":       now.Unix(),
+		"exp":       expiresAt.Unix(),
+	}
+	if len(amr) > 0 {
+		claims["amr"] = amr
+	}
+	signed, err := Keys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+// ParsePurposeToken verifies a token from GeneratePurposeToken that was
+// issued for use.
+func ParsePurposeToken(tokenStr, use string) (*PurposeClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, Keys.Keyfunc, jwt.WithExpirationRequired())
+	if err != nil || !token.Valid || claims["token_use"] != use {
+		return nil, ErrInvalidPurposeToken
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, ErrInvalidPurposeToken
+	}
+	jti, _ := claims["jti"].(string)
+	expiresAt, _ := claims.GetExpirationTime()
+	var amr []string
+	if values, ok := claims["amr"].([]interface{}); ok {
+		for _, value := range values {
+			if method, ok := value.(string); ok {
+				amr = append(amr, method)
+			}
+		}
+	}
+	return &PurposeClaims{UserID: userID, JTI: jti, ExpiresAt: expiresAt.Time, AMR: amr}, nil
+}
+
+// GenerateOpaqueToken returns a random URL-safe token with 256 bits of entropy.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token, which is what gets
+// persisted. A fast hash is enough because the tokens are high entropy.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }